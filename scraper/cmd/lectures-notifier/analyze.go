@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// analyzeDedupe is the read-only counterpart of the SetNX path in
+// filterEvents, used for DEDUP_ANALYZE: it reports whether e would notify
+// under cfg using only GET and EXISTS.
+func analyzeDedupe(ctx context.Context, redisClient *redis.Client, e event, startTime time.Time, hasStart bool, cfg dedupeConfig, now time.Time, m *metrics.Metrics) bool {
+	redisKey := dedupeKey(e.ID)
+	current, err := redisClient.Get(ctx, redisKey).Result()
+	switch {
+	case errors.Is(err, redis.Nil):
+		// Not notified (or the reminder cooldown lapsed); URL and reminder keys decide.
+	case err != nil:
+		log.Printf("analyze: redis get failed for %s (event %s): %v (counting as would-notify)", redisKey, e.ID, err)
+		recordRedisError(err, m)
+		return true
+	default:
+		if cfg.notifyOnRestock {
+			if rec, err := parseDedupeRecord(current); err == nil && rec.SoldOut {
+				log.Printf("analyze: event %s (%s) would notify as a restock", e.ID, e.Name.Text)
+				return true
+			}
+		}
+		log.Printf("analyze: event %s (%s) would be deduped by %s", e.ID, e.Name.Text, redisKey)
+		return false
+	}
+
+	for _, urlKey := range eventDedupeKeys(e, cfg)[1:] {
+		if exists(ctx, redisClient, urlKey, m) {
+			log.Printf("analyze: event %s (%s) would be deduped by %s", e.ID, e.Name.Text, urlKey)
+			return false
+		}
+	}
+
+	if remindersEnabled(cfg) && exists(ctx, redisClient, announcedKey(e.ID), m) {
+		if !hasStart || startTime.Sub(now) > cfg.reminderWindow || exists(ctx, redisClient, remindedKey(e.ID), m) {
+			log.Printf("analyze: event %s (%s) was announced and no reminder is due", e.ID, e.Name.Text)
+			return false
+		}
+		log.Printf("analyze: event %s (%s) would notify as a reminder", e.ID, e.Name.Text)
+		return true
+	}
+
+	log.Printf("analyze: event %s (%s) would notify", e.ID, e.Name.Text)
+	return true
+}
+
+func exists(ctx context.Context, redisClient *redis.Client, key string, m *metrics.Metrics) bool {
+	n, err := redisClient.Exists(ctx, key).Result()
+	if err != nil {
+		log.Printf("analyze: redis exists failed for %s: %v", key, err)
+		recordRedisError(err, m)
+		return false
+	}
+	return n > 0
+}
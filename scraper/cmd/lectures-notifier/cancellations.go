@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"net/http"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/metrics"
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/notifications"
+	"github.com/redis/go-redis/v9"
+)
+
+// canceledPrefix leads the message sent when a notified event is canceled.
+const canceledPrefix = "❌ Canceled:"
+
+// notifyCanceledEvents fetches upcoming canceled events and, for those we
+// previously notified (their dedupe key still exists), sends a cancellation
+// notice and drops the event's dedupe keys. Failures are logged and never
+// fail the run; without Redis there is no way to tell what was notified. It
+// reports whether a Redis error pointed at misconfiguration so the caller can
+// honour REDIS_FAIL_ON_MISCONFIG.
+func notifyCanceledEvents(ctx context.Context, httpClient *http.Client, cfg appConfig, redisClient *redis.Client, dedupeCfg dedupeConfig, notifier *notifications.MultiNotifier, m *metrics.Metrics) (misconfigured bool) {
+	if redisClient == nil {
+		slog.Warn("cancellation notifications need redis to know which events were notified, skipping", "stage", "cancellations")
+		return false
+	}
+	noteRedisError := func(err error) {
+		if recordRedisError(err, m).misconfiguration() {
+			misconfigured = true
+		}
+	}
+	fetchCfg := cfg.fetch
+	fetchCfg.status = "canceled"
+	canceled, err := fetchAllOrganizers(ctx, httpClient, cfg.orgIDs, cfg.token, fetchCfg, m)
+	if err != nil {
+		slog.Error("failed to fetch canceled events, skipping cancellation notifications", "stage", "cancellations", "error", err)
+		return false
+	}
+	slog.Info("fetched upcoming canceled events", "stage", "cancellations", "events", len(canceled))
+
+	for _, e := range canceled {
+		if err := ctx.Err(); err != nil {
+			return misconfigured
+		}
+		redisKey := dedupeKey(e.ID)
+		n, err := redisClient.Exists(ctx, redisKey).Result()
+		if err != nil {
+			slog.Error("redis exists failed", "stage", "cancellations", "key", redisKey, "event_id", e.ID, "error", err)
+			noteRedisError(err)
+			continue
+		}
+		if n == 0 {
+			continue
+		}
+
+		note := eventNotification(e, cfg.message, cfg.tagField)
+		note.Prefix = canceledPrefix
+		if cfg.isLocal {
+			msg := notifications.PlainText(note)
+			slog.Info("local mode: printing cancellation to stdout", "stage", "cancellations", "event_id", e.ID, "bytes", len(msg))
+			log.Println(msg)
+		} else {
+			note.Tags = []string{"x"}
+			if state := notifications.TopicSlug(eventState(e)); state != "" {
+				note.Tags = append(note.Tags, state)
+			}
+			routed := notifications.NewMultiNotifier(routeNotifiers(e, notifier.Notifiers(), cfg.routes)...)
+			if len(deliveredNames(routed.NotifyAll(ctx, note))) == 0 {
+				slog.Warn("cancellation notice was not delivered, keeping dedupe key for the next run", "stage", "cancellations", "event_id", e.ID, "event_name", e.Name.Text)
+				continue
+			}
+		}
+		m.RecordEventCanceled()
+
+		keys := eventDedupeKeys(e, dedupeCfg)
+		if remindersEnabled(dedupeCfg) {
+			keys = append(keys, announcedKey(e.ID), remindedKey(e.ID))
+		}
+		for _, key := range keys {
+			if _, err := deleteKey(ctx, redisClient, key); err != nil {
+				slog.Error("redis delete failed", "stage", "cancellations", "key", key, "event_id", e.ID, "error", err)
+				noteRedisError(err)
+			}
+		}
+		slog.Info("sent cancellation notice", "stage", "cancellations", "event_id", e.ID, "event_name", e.Name.Text)
+	}
+	return misconfigured
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/notifications"
+)
+
+// recordingNotifier keeps every notification it is asked to send and fails
+// them all when err is set.
+type recordingNotifier struct {
+	notes []notifications.Notification
+	err   error
+}
+
+func (*recordingNotifier) Name() string { return "ntfy" }
+
+func (r *recordingNotifier) Notify(_ context.Context, n notifications.Notification) error {
+	r.notes = append(r.notes, n)
+	return r.err
+}
+
+func TestNotifyCanceledEventsKeepsWrongTypeKey(t *testing.T) {
+	client, fake := newFakeRedis(t)
+	now := time.Now()
+	canceled := upcomingEvent("1", "Pints of Science", "Montreal", now.Add(48*time.Hour))
+	canceled.URL = "https://www.eventbrite.ca/e/pints-1"
+	httpClient := newEventBriteClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("status"); got != "canceled" {
+			t.Errorf("status = %q, want canceled", got)
+		}
+		json.NewEncoder(w).Encode(eventBritePage([]event{canceled}, 1))
+	}))
+
+	dedupeCfg := buildDedupeConfig()
+	dedupeCfg.byURL = true
+	urlKey := urlDedupeKey(normalizeEventURL(canceled.URL))
+	fake.set(dedupeKey("1"), legacyDedupeValue, time.Hour)
+	fake.setType(urlKey, "hash")
+
+	cfg := appConfig{orgIDs: []string{"org"}, token: "token", isLocal: true}
+	if !notifyCanceledEvents(context.Background(), httpClient, cfg, client, dedupeCfg, nil, nil) {
+		t.Fatal("notifyCanceledEvents() = false, want the wrong-type key reported as misconfiguration")
+	}
+	if keys := fake.keyNames(); !reflect.DeepEqual(keys, []string{urlKey}) {
+		t.Fatalf("keys = %v, want only the wrong-type %s kept", keys, urlKey)
+	}
+}
+
+func TestNotifyCanceledEventsSendsNoticeAndDeletesKey(t *testing.T) {
+	now := time.Now()
+	notified := upcomingEvent("1", "Pints of Science", "Montreal", now.Add(48*time.Hour))
+	neverNotified := upcomingEvent("2", "Brain Night", "Montreal", now.Add(48*time.Hour))
+	httpClient := newEventBriteClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(eventBritePage([]event{notified, neverNotified}, 1))
+	}))
+	cfg := appConfig{orgIDs: []string{"org"}, token: "token"}
+
+	for _, tc := range []struct {
+		name    string
+		sendErr error
+		wantKey bool
+	}{
+		{"delivered", nil, false},
+		{"not delivered", errors.New("ntfy down"), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client, fake := newFakeRedis(t)
+			fake.set(dedupeKey("1"), legacyDedupeValue, time.Hour)
+			rec := &recordingNotifier{err: tc.sendErr}
+
+			if notifyCanceledEvents(context.Background(), httpClient, cfg, client, buildDedupeConfig(), notifications.NewMultiNotifier(rec), nil) {
+				t.Fatal("notifyCanceledEvents() reported misconfiguration")
+			}
+			if len(rec.notes) != 1 || rec.notes[0].EventID != "1" || rec.notes[0].Prefix != canceledPrefix {
+				t.Fatalf("notifications = %+v, want one %q notice for event 1", rec.notes, canceledPrefix)
+			}
+			if _, ok := fake.get(dedupeKey("1")); ok != tc.wantKey {
+				t.Fatalf("dedupe key kept = %t, want %t", ok, tc.wantKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// clickTrackingConfig wraps event links through a redirect endpoint that
+// records the click before sending the user on to EventBrite.
+type clickTrackingConfig struct {
+	base   string
+	secret string
+}
+
+func buildClickTrackingConfig() clickTrackingConfig {
+	cfg := clickTrackingConfig{
+		base:   strings.TrimRight(strings.TrimSpace(os.Getenv("CLICK_TRACKING_BASE")), "/"),
+		secret: strings.TrimSpace(os.Getenv("CLICK_TRACKING_SECRET")),
+	}
+	if cfg.base != "" && cfg.secret == "" {
+		log.Printf("CLICK_TRACKING_BASE set without CLICK_TRACKING_SECRET, click tracking disabled")
+		cfg.base = ""
+	}
+	return cfg
+}
+
+// signClick signs the redirect parameters so the endpoint only forwards to
+// URLs the scraper produced. The signed string is "<eventID>\n<topic>\n<target>"
+// and the signature is hex-encoded HMAC-SHA256 keyed with the shared secret.
+func signClick(secret, eventID, topic, target string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(eventID + "\n" + topic + "\n" + target))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// trackedURL returns the redirect URL for an event, or the event URL itself
+// when tracking is disabled or the event has no URL.
+func trackedURL(e event, cfg clickTrackingConfig) string {
+	target := strings.TrimSpace(e.URL)
+	if cfg.base == "" || target == "" {
+		return target
+	}
+	topic := strings.ToLower(eventState(e))
+	q := url.Values{}
+	q.Set("e", e.ID)
+	q.Set("t", topic)
+	q.Set("u", target)
+	q.Set("s", signClick(cfg.secret, e.ID, topic, target))
+	return cfg.base + "/r?" + q.Encode()
+}
+
+// applyClickTracking rewrites event URLs to their tracked form. It runs after
+// filtering so dedupe keeps operating on the original EventBrite URLs.
+func applyClickTracking(events []event, cfg clickTrackingConfig) []event {
+	if cfg.base == "" {
+		return events
+	}
+	out := make([]event, len(events))
+	for i, e := range events {
+		e.sourceURL = e.URL
+		e.URL = trackedURL(e, cfg)
+		out[i] = e
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// logConfig emits one line summarizing the effective configuration. Tokens
+// and passwords only report whether they are set; URLs that embed secrets
+// (webhooks, healthchecks) are reduced to scheme and host.
+func logConfig(cfg appConfig) {
+	mode := "production"
+	if cfg.isLocal {
+		mode = "local"
+	}
+	redisAddr := strings.TrimSpace(os.Getenv("REDIS_ADDR"))
+	dedupeCfg := cfg.dedupe
+
+	fields := []struct{ key, value string }{
+		{"mode", mode},
+		{"log_format", strings.TrimSpace(os.Getenv("LOG_FORMAT"))},
+		{"organizer_ids", strings.Join(cfg.orgIDs, ",")},
+		{"eventbrite_token", redactSecret(cfg.token)},
+		{"eventbrite_fetch_concurrency", fmt.Sprint(cfg.fetch.concurrency)},
+		{"eventbrite_page_delay", cfg.fetch.pageDelay.String()},
+		{"eventbrite_rate_limit_retries", fmt.Sprint(cfg.fetch.rateLimitRetries)},
+		{"eventbrite_max_events", fmt.Sprint(cfg.fetch.maxEvents)},
+		{"outbound_disable_http2", fmt.Sprint(cfg.outbound.disableHTTP2)},
+		{"outbound_tls_min_version", tlsVersionName(cfg.outbound.minTLSVersion)},
+		{"outbound_ca_file", cfg.outbound.caFile},
+		{"ntfy_topics", redactURLList(cfg.notify.NtfyTopicURLs)},
+		{"ntfy_token", redactSecret(cfg.notify.NtfyToken)},
+		{"ntfy_markdown", fmt.Sprint(cfg.notify.NtfyMarkdown)},
+		{"ntfy_tag_field", cfg.tagField},
+		{"discord_enabled", fmt.Sprint(cfg.notify.DiscordWebhookURL != "")},
+		{"discord_webhook", redactURLHost(cfg.notify.DiscordWebhookURL)},
+		{"webhook_url", redactURLHost(cfg.notify.WebhookURL)},
+		{"webhook_token", redactSecret(cfg.notify.WebhookToken)},
+		{"webhook_secret", redactSecret(cfg.notify.WebhookSecret)},
+		{"smtp_host", cfg.notify.Email.Host},
+		{"smtp_port", fmt.Sprint(cfg.notify.Email.Port)},
+		{"smtp_username", cfg.notify.Email.Username},
+		{"smtp_password", redactSecret(cfg.notify.Email.Password)},
+		{"smtp_to", strings.Join(cfg.notify.Email.To, ",")},
+		{"healthchecks", redactURLHost(cfg.healthchecksPingURL)},
+		{"empty_runs_alert_threshold", fmt.Sprint(cfg.emptyRunsAlert)},
+		{"healthchecks_empty_runs", redactURLHost(cfg.emptyRunsPingURL)},
+		{"run_report_path", cfg.runReportPath},
+		{"click_tracking_base", cfg.clickTracking.base},
+		{"click_tracking_secret", redactSecret(cfg.clickTracking.secret)},
+		{"redis_enabled", fmt.Sprint(redisAddr != "")},
+		{"redis_addr", redisAddr},
+		{"redis_key_prefix", loadRedisKeyPrefix()},
+		{"redis_username", strings.TrimSpace(os.Getenv("REDIS_USERNAME"))},
+		{"redis_password", redactSecret(os.Getenv("REDIS_PASSWORD"))},
+		{"redis_tls", fmt.Sprint(envBool("REDIS_TLS", false))},
+		{"redis_tls_skip_verify", fmt.Sprint(envBool("REDIS_TLS_SKIP_VERIFY", false))},
+		{"redis_fail_on_misconfig", fmt.Sprint(cfg.redisFailOnMisconf)},
+		{"dedupe_disabled", fmt.Sprint(envBool("DEDUP_DISABLE", false))},
+		{"dedupe_max_ttl", dedupeCfg.ttlCap.String()},
+		{"dedupe_reminder_cooldown", dedupeCfg.reminderCooldown.String()},
+		{"reminder_window", dedupeCfg.reminderWindow.String()},
+		{"dedupe_delete_on_sold_out", fmt.Sprint(dedupeCfg.deleteOnSoldOut)},
+		{"dedupe_notify_on_restock", fmt.Sprint(dedupeCfg.notifyOnRestock)},
+		{"dedupe_ttl_jitter", dedupeCfg.ttlJitter.String()},
+		{"dedupe_by_url", fmt.Sprint(dedupeCfg.byURL)},
+		{"dedupe_analyze", fmt.Sprint(cfg.dedupeAnalyze)},
+		{"catchup", fmt.Sprint(cfg.catchup)},
+		{"dedupe_value_format", dedupeValueFormat(dedupeCfg)},
+		{"price_filter", cfg.filter.priceFilter},
+		{"notify_keywords", strings.Join(cfg.filter.keywords, ",")},
+		{"notify_keywords_deny", strings.Join(cfg.filter.denyKeywords, ",")},
+		{"skip_sales_ended", fmt.Sprint(cfg.filter.skipSalesEnded)},
+		{"collapse_recurring_key", cfg.filter.collapseKey},
+		{"locale", cfg.message.locale},
+		{"capacity_threshold", fmt.Sprint(cfg.message.capacityThreshold)},
+		{"digest_mode", fmt.Sprint(cfg.digestMode)},
+		{"digest_max_items", fmt.Sprint(cfg.digestMaxItems)},
+		{"notifier_routes", fmt.Sprint(len(cfg.routes))},
+		{"notify_cancellations", fmt.Sprint(cfg.notifyCancellations)},
+		{"validate_organizer", fmt.Sprint(cfg.validateOrganizer)},
+		{"run_lock", fmt.Sprint(cfg.runLock.enabled)},
+		{"run_lock_ttl", cfg.runLock.ttl.String()},
+		{"soft_run_budget", cfg.softRunBudget.String()},
+		{"inject_test_event", fmt.Sprint(cfg.injectTestEvent)},
+	}
+
+	var b strings.Builder
+	b.WriteString("effective config:")
+	for _, f := range fields {
+		fmt.Fprintf(&b, " %s=%q", f.key, f.value)
+	}
+	log.Print(b.String())
+}
+
+func redactSecret(v string) string {
+	if strings.TrimSpace(v) == "" {
+		return "<unset>"
+	}
+	return "<redacted>"
+}
+
+// redactURLHost keeps only the scheme and host of a URL whose path or
+// userinfo may carry a secret.
+func redactURLHost(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "<unset>"
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "<redacted>"
+	}
+	return u.Scheme + "://" + u.Host + "/<redacted>"
+}
+
+// redactURLList strips userinfo from each comma-separated URL but keeps the
+// path, since ntfy topic names are not secret.
+func redactURLList(raw string) string {
+	if strings.TrimSpace(raw) == "" {
+		return "<unset>"
+	}
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		u, err := url.Parse(part)
+		if err != nil {
+			out = append(out, "<redacted>")
+			continue
+		}
+		if u.User != nil {
+			u.User = url.User("redacted")
+		}
+		u.RawQuery = ""
+		out = append(out, u.String())
+	}
+	return strings.Join(out, ",")
+}
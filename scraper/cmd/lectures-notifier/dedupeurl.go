@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// normalizeEventURL reduces an event URL to the form used for URL dedupe:
+// lowercase scheme and host, no query, fragment or trailing slash. It
+// returns "" for URLs that cannot be parsed, have no host, or have no path:
+// a bare host such as https://www.eventbrite.com/ is shared by unrelated
+// events and would dedupe them all against each other.
+func normalizeEventURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return ""
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u.String()
+}
+
+func urlDedupeKey(normalizedURL string) string {
+	return redisKeyPrefix + "url:" + normalizedURL
+}
+
+// dedupeURL returns the EventBrite URL of an event, ignoring any
+// click-tracking rewrite applied after filtering.
+func dedupeURL(e event) string {
+	if e.sourceURL != "" {
+		return e.sourceURL
+	}
+	return e.URL
+}
+
+// eventDedupeKeys lists every dedupe key an event may hold under cfg. The
+// synthetic test event never gets a URL key.
+func eventDedupeKeys(e event, cfg dedupeConfig) []string {
+	keys := []string{dedupeKey(e.ID)}
+	if cfg.byURL && !e.test {
+		if u := normalizeEventURL(dedupeURL(e)); u != "" {
+			keys = append(keys, urlDedupeKey(u))
+		}
+	}
+	return keys
+}
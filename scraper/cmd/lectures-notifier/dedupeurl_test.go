@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNormalizeEventURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"https://www.eventbrite.ca/e/pints-of-science-tickets-123?aff=ebdsoporgprofile#tickets", "https://www.eventbrite.ca/e/pints-of-science-tickets-123"},
+		{"  HTTPS://WWW.Eventbrite.CA/e/Pints-Tickets-123/  ", "https://www.eventbrite.ca/e/Pints-Tickets-123"},
+		{"https://www.eventbrite.ca/e/pints-tickets-123?", "https://www.eventbrite.ca/e/pints-tickets-123"},
+		{"https://www.eventbrite.com/", ""},
+		{"https://www.eventbrite.com", ""},
+		{"https://www.eventbrite.com/?aff=1", ""},
+		{"/e/pints-tickets-123", ""},
+		{"", ""},
+		{"://bad", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeEventURL(tt.raw); got != tt.want {
+			t.Errorf("normalizeEventURL(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestEventDedupeKeysRepostedWithNewID(t *testing.T) {
+	cfg := dedupeConfig{byURL: true}
+	original := event{ID: "111", URL: "https://www.eventbrite.ca/e/pints-tickets-111?aff=ebdssbdestsearch"}
+	repost := event{ID: "222", URL: "https://WWW.eventbrite.ca/e/pints-tickets-111#tickets"}
+
+	origKeys := eventDedupeKeys(original, cfg)
+	repostKeys := eventDedupeKeys(repost, cfg)
+	if len(origKeys) != 2 || len(repostKeys) != 2 {
+		t.Fatalf("expected ID and URL keys, got %v and %v", origKeys, repostKeys)
+	}
+	if origKeys[0] == repostKeys[0] {
+		t.Fatalf("ID keys should differ, both %s", origKeys[0])
+	}
+	if origKeys[1] != repostKeys[1] {
+		t.Fatalf("URL keys differ: %s vs %s", origKeys[1], repostKeys[1])
+	}
+}
+
+func TestEventDedupeKeysSkipsURLKey(t *testing.T) {
+	cfg := dedupeConfig{byURL: true}
+	tests := []struct {
+		name string
+		e    event
+	}{
+		{"bare host", event{ID: "1", URL: "https://www.eventbrite.com/"}},
+		{"empty url", event{ID: "2"}},
+		{"test event", func() event {
+			e := syntheticTestEvent(time.Now())
+			e.URL = "https://www.eventbrite.ca/e/pints-tickets-111"
+			return e
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := []string{dedupeKey(tt.e.ID)}
+			if got := eventDedupeKeys(tt.e, cfg); !reflect.DeepEqual(got, want) {
+				t.Fatalf("eventDedupeKeys() = %v, want %v", got, want)
+			}
+		})
+	}
+}
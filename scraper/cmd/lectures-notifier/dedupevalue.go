@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// legacyDedupeValue is the marker stored before dedupe values carried metadata.
+const legacyDedupeValue = "1"
+
+// dedupeRecord is the JSON value stored under an event's dedupe key when
+// DEDUP_VALUE_FORMAT=json.
+type dedupeRecord struct {
+	NotifiedAt   int64    `json:"notified_at"`
+	MessageHash  string   `json:"message_hash,omitempty"`
+	Destinations []string `json:"destinations,omitempty"`
+	// SoldOut marks an event last seen sold out (DEDUP_NOTIFY_ON_RESTOCK).
+	SoldOut   bool  `json:"sold_out,omitempty"`
+	SoldOutAt int64 `json:"sold_out_at,omitempty"`
+	// Legacy is set when the stored value was the plain "1" marker.
+	Legacy bool `json:"-"`
+}
+
+// dedupeValue returns the value written when a dedupe key is first claimed.
+func dedupeValue(cfg dedupeConfig, now time.Time) string {
+	if !cfg.jsonValue {
+		return legacyDedupeValue
+	}
+	return encodeDedupeRecord(dedupeRecord{NotifiedAt: now.Unix()})
+}
+
+func dedupeValueFormat(cfg dedupeConfig) string {
+	if cfg.jsonValue {
+		return "json"
+	}
+	return "legacy"
+}
+
+func encodeDedupeRecord(rec dedupeRecord) string {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return legacyDedupeValue
+	}
+	return string(b)
+}
+
+// parseDedupeRecord decodes a stored dedupe value, accepting the legacy "1" marker.
+func parseDedupeRecord(value string) (dedupeRecord, error) {
+	value = strings.TrimSpace(value)
+	if value == legacyDedupeValue {
+		return dedupeRecord{Legacy: true}, nil
+	}
+	var rec dedupeRecord
+	if err := json.Unmarshal([]byte(value), &rec); err != nil {
+		return dedupeRecord{}, fmt.Errorf("parse dedupe value %q: %w", value, err)
+	}
+	return rec, nil
+}
+
+// swapDedupeValueScript replaces a key's value only if it still holds old, keeping
+// its TTL, so two overlapping runs cannot both act on the same value.
+var swapDedupeValueScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2], "KEEPTTL")
+	return 1
+end
+return 0
+`)
+
+// compareAndSwap sets key to next if it still holds current and reports
+// whether it did.
+func compareAndSwap(ctx context.Context, redisClient *redis.Client, key, current, next string) (bool, error) {
+	swapped, err := swapDedupeValueScript.Run(ctx, redisClient, []string{key}, current, next).Int()
+	return swapped == 1, err
+}
+
+func messageHash(msg string) string {
+	sum := sha256.Sum256([]byte(msg))
+	return hex.EncodeToString(sum[:8])
+}
+
+// recordDedupeDelivery updates an event's dedupe value with the message hash and
+// the destinations that accepted it, keeping the key's existing TTL.
+func recordDedupeDelivery(ctx context.Context, redisClient *redis.Client, cfg dedupeConfig, e event, msg string, destinations []string, m *metrics.Metrics) {
+	if redisClient == nil || !cfg.jsonValue {
+		return
+	}
+	redisKey := dedupeKey(e.ID)
+	rec := dedupeRecord{NotifiedAt: time.Now().Unix()}
+	if current, err := redisClient.Get(ctx, redisKey).Result(); err == nil {
+		if parsed, err := parseDedupeRecord(current); err != nil {
+			slog.Warn("redis dedupe value unreadable, overwriting", "stage", "notify", "key", redisKey, "event_id", e.ID, "error", err)
+		} else if !parsed.Legacy && parsed.NotifiedAt > 0 {
+			rec.NotifiedAt = parsed.NotifiedAt
+		}
+	} else if err != redis.Nil {
+		slog.Error("redis get failed", "stage", "notify", "key", redisKey, "event_id", e.ID, "error", err)
+		recordRedisError(err, m)
+		return
+	}
+	rec.MessageHash = messageHash(msg)
+	rec.Destinations = destinations
+
+	if err := redisClient.SetArgs(ctx, redisKey, encodeDedupeRecord(rec), redis.SetArgs{KeepTTL: true, Mode: "XX"}).Err(); err != nil && err != redis.Nil {
+		slog.Error("redis set failed", "stage", "notify", "key", redisKey, "event_id", e.ID, "error", err)
+		recordRedisError(err, m)
+	}
+}
+
+func (r dedupeRecord) String() string {
+	if r.Legacy {
+		return "legacy marker"
+	}
+	parts := []string{"notified_at=" + time.Unix(r.NotifiedAt, 0).UTC().Format(time.RFC3339)}
+	if r.MessageHash != "" {
+		parts = append(parts, "message_hash="+r.MessageHash)
+	}
+	if len(r.Destinations) > 0 {
+		parts = append(parts, "destinations="+strings.Join(r.Destinations, ","))
+	}
+	if r.SoldOut {
+		parts = append(parts, "sold_out_at="+time.Unix(r.SoldOutAt, 0).UTC().Format(time.RFC3339))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDedupeRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    dedupeRecord
+		wantErr bool
+	}{
+		{"legacy marker", "1", dedupeRecord{Legacy: true}, false},
+		{"legacy marker with whitespace", " 1\n", dedupeRecord{Legacy: true}, false},
+		{"json", `{"notified_at":1800000000,"message_hash":"abc","destinations":["ntfy","discord"]}`, dedupeRecord{NotifiedAt: 1800000000, MessageHash: "abc", Destinations: []string{"ntfy", "discord"}}, false},
+		{"json sold out", `{"notified_at":1800000000,"sold_out":true,"sold_out_at":1800003600}`, dedupeRecord{NotifiedAt: 1800000000, SoldOut: true, SoldOutAt: 1800003600}, false},
+		{"garbage", "yes", dedupeRecord{}, true},
+		{"empty", "", dedupeRecord{}, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseDedupeRecord(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseDedupeRecord(%q) error = %v, wantErr %t", tc.value, err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("parseDedupeRecord(%q) = %+v, want %+v", tc.value, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDedupeValueRoundTrip(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	if got := dedupeValue(dedupeConfig{}, now); got != legacyDedupeValue {
+		t.Fatalf("dedupeValue(legacy) = %q, want %q", got, legacyDedupeValue)
+	}
+	rec, err := parseDedupeRecord(dedupeValue(dedupeConfig{jsonValue: true}, now))
+	if err != nil {
+		t.Fatalf("parseDedupeRecord(json value) error = %v", err)
+	}
+	if want := (dedupeRecord{NotifiedAt: now.Unix()}); !reflect.DeepEqual(rec, want) {
+		t.Fatalf("round trip = %+v, want %+v", rec, want)
+	}
+}
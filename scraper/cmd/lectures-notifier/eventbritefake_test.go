@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// newEventBriteClient returns an HTTP client whose requests to the EventBrite
+// API are answered by handler instead, so tests drive the real fetch code
+// without network access.
+func newEventBriteClient(t *testing.T, handler http.Handler) *http.Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+	return &http.Client{Transport: rewriteTransport{target: target, next: srv.Client().Transport}}
+}
+
+// rewriteTransport sends every request to target, keeping path and query.
+type rewriteTransport struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (rt rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = rt.target.Scheme
+	req.URL.Host = rt.target.Host
+	req.Host = rt.target.Host
+	return rt.next.RoundTrip(req)
+}
+
+// eventBritePage builds one page of an EventBrite events response.
+func eventBritePage(events []event, pageCount int) ebResp {
+	r := ebResp{Events: events}
+	r.Pagination.PageCount = pageCount
+	return r
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// outboundConfig tunes the HTTP client shared by the EventBrite fetch and the
+// notifiers, for environments with misbehaving proxies or private CAs.
+type outboundConfig struct {
+	disableHTTP2  bool
+	minTLSVersion uint16
+	caFile        string
+}
+
+func buildOutboundConfig() outboundConfig {
+	cfg := outboundConfig{
+		disableHTTP2:  envBool("OUTBOUND_DISABLE_HTTP2", false),
+		minTLSVersion: tls.VersionTLS12,
+		caFile:        strings.TrimSpace(os.Getenv("OUTBOUND_CA_FILE")),
+	}
+	switch v := strings.TrimSpace(os.Getenv("OUTBOUND_TLS_MIN_VERSION")); v {
+	case "", "1.2":
+	case "1.3":
+		cfg.minTLSVersion = tls.VersionTLS13
+	default:
+		log.Printf("unknown OUTBOUND_TLS_MIN_VERSION %q, defaulting to 1.2", v)
+	}
+	return cfg
+}
+
+// newHTTPClient builds the outbound client. A CA bundle is added to the
+// system roots rather than replacing them, so public endpoints keep working.
+func newHTTPClient(cfg outboundConfig, timeout time.Duration) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{MinVersion: cfg.minTLSVersion}
+
+	if cfg.caFile != "" {
+		pem, err := os.ReadFile(cfg.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read OUTBOUND_CA_FILE: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in OUTBOUND_CA_FILE %s", cfg.caFile)
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	if cfg.disableHTTP2 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+func tlsVersionName(v uint16) string {
+	if v == tls.VersionTLS13 {
+		return "1.3"
+	}
+	return "1.2"
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClientTrustsOutboundCAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OUTBOUND_CA_FILE", caFile)
+
+	client, err := newHTTPClient(buildOutboundConfig(), 5*time.Second)
+	if err != nil {
+		t.Fatalf("newHTTPClient() error = %v", err)
+	}
+	tlsConfig := client.Transport.(*http.Transport).TLSClientConfig
+	if tlsConfig.RootCAs == nil {
+		t.Fatal("RootCAs is nil, want the OUTBOUND_CA_FILE certificate added")
+	}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET with the CA trusted error = %v", err)
+	}
+	resp.Body.Close()
+
+	defaultClient, err := newHTTPClient(outboundConfig{}, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := defaultClient.Get(srv.URL); err == nil {
+		t.Fatal("GET without OUTBOUND_CA_FILE trusted the test server's certificate")
+	}
+}
+
+func TestNewHTTPClientRejectsCAFileWithoutCertificates(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newHTTPClient(outboundConfig{caFile: caFile}, time.Second); err == nil {
+		t.Fatal("newHTTPClient() with an empty CA bundle succeeded")
+	}
+}
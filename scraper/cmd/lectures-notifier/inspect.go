@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// runInspect prints the Redis state kept for an event ID and returns the
+// process exit code. It never modifies Redis.
+func runInspect(eventID string) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	redisClient := newRedisClient(true)
+	if redisClient == nil {
+		log.Printf("inspect: REDIS_ADDR not set")
+		return 1
+	}
+	defer redisClient.Close()
+
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		log.Printf("inspect: redis ping failed: %v", err)
+		return 1
+	}
+
+	// Match the dedupe key as well as any sibling keys stored under the same event prefix.
+	key := dedupeKey(eventID)
+	keys := []string{key}
+	iter := redisClient.Scan(ctx, 0, eventKeyPrefix(eventID)+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		if iter.Val() != key {
+			keys = append(keys, iter.Val())
+		}
+	}
+	if err := iter.Err(); err != nil {
+		log.Printf("inspect: redis scan failed: %v", err)
+		return 1
+	}
+
+	fmt.Printf("event %s\n", eventID)
+	for _, k := range keys {
+		printInspectKey(ctx, redisClient, k)
+	}
+	return 0
+}
+
+func printInspectKey(ctx context.Context, redisClient *redis.Client, key string) {
+	keyType, err := redisClient.Type(ctx, key).Result()
+	if err != nil {
+		fmt.Printf("  %s: error: %v\n", key, err)
+		return
+	}
+	if keyType == "none" {
+		fmt.Printf("  %s: not set\n", key)
+		return
+	}
+
+	value := "<" + keyType + ">"
+	if keyType == "string" {
+		if value, err = redisClient.Get(ctx, key).Result(); err != nil {
+			value = fmt.Sprintf("<error: %v>", err)
+		}
+	}
+
+	ttl, err := redisClient.TTL(ctx, key).Result()
+	ttlStr := ttl.String()
+	switch {
+	case err != nil:
+		ttlStr = fmt.Sprintf("<error: %v>", err)
+	case ttl == -1:
+		ttlStr = "no expiry"
+	}
+	fmt.Printf("  %s: value=%q ttl=%s\n", key, value, ttlStr)
+	if keyType == "string" && strings.HasSuffix(key, ":notified") {
+		if rec, err := parseDedupeRecord(value); err != nil {
+			fmt.Printf("    decoded: <%v>\n", err)
+		} else {
+			fmt.Printf("    decoded: %s\n", rec)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const defaultLocale = "en"
+
+// timeLocale holds abbreviated weekday/month names and the order in which
+// they are assembled, since the stdlib only formats English names.
+type timeLocale struct {
+	weekdays [7]string // indexed by time.Weekday
+	months   [12]string
+	format   func(weekday string, day int, month, clock string) string
+}
+
+var timeLocales = map[string]timeLocale{
+	"fr": {
+		weekdays: [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		months:   [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		format: func(weekday string, day int, month, clock string) string {
+			return fmt.Sprintf("%s %d %s à %s", weekday, day, month, clock)
+		},
+	},
+	"es": {
+		weekdays: [7]string{"dom.", "lun.", "mar.", "mié.", "jue.", "vie.", "sáb."},
+		months:   [12]string{"ene.", "feb.", "mar.", "abr.", "may.", "jun.", "jul.", "ago.", "sept.", "oct.", "nov.", "dic."},
+		format: func(weekday string, day int, month, clock string) string {
+			return fmt.Sprintf("%s %d de %s a las %s", weekday, day, month, clock)
+		},
+	},
+}
+
+var reminderPrefixes = map[string]string{
+	"en": "Reminder:",
+	"fr": "Rappel :",
+	"es": "Recordatorio:",
+}
+
+// reminderPrefix returns the word that leads a reminder notification.
+func reminderPrefix(locale string) string {
+	if p, ok := reminderPrefixes[normalizeLocale(locale)]; ok {
+		return p
+	}
+	return reminderPrefixes[defaultLocale]
+}
+
+// normalizeLocale reduces values like "fr-CA" or "fr_CA" to their language
+// code, falling back to English when the language has no translation table.
+func normalizeLocale(locale string) string {
+	lang := strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := timeLocales[lang]; !ok {
+		return defaultLocale
+	}
+	return lang
+}
+
+func formatEventTime(t time.Time, locale string) string {
+	loc, ok := timeLocales[normalizeLocale(locale)]
+	if !ok {
+		return t.Format("Mon, Jan 2 at 15:04")
+	}
+	return loc.format(loc.weekdays[t.Weekday()], t.Day(), loc.months[t.Month()-1], t.Format("15:04"))
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := map[string]string{
+		"":      "en",
+		"en":    "en",
+		"fr":    "fr",
+		"fr-CA": "fr",
+		"FR_ca": "fr",
+		" fr ":  "fr",
+		"de":    "en",
+	}
+	for in, want := range tests {
+		if got := normalizeLocale(in); got != want {
+			t.Errorf("normalizeLocale(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFormatEventTime(t *testing.T) {
+	start := time.Date(2026, 3, 5, 19, 30, 0, 0, time.UTC)
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"en", "Thu, Mar 5 at 19:30"},
+		{"", "Thu, Mar 5 at 19:30"},
+		{"fr", "jeu. 5 mars à 19:30"},
+		{"fr-CA", "jeu. 5 mars à 19:30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got := formatEventTime(start, tt.locale); got != tt.want {
+				t.Fatalf("formatEventTime(%q) = %q, want %q", tt.locale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReminderPrefix(t *testing.T) {
+	tests := map[string]string{
+		"en":    "Reminder:",
+		"fr":    "Rappel :",
+		"fr-CA": "Rappel :",
+		"de":    "Reminder:",
+	}
+	for locale, want := range tests {
+		if got := reminderPrefix(locale); got != want {
+			t.Errorf("reminderPrefix(%q) = %q, want %q", locale, got, want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// setupLogging selects the log/slog handler from LOG_FORMAT. "json" writes
+// one JSON object per line, including lines still logged through the log
+// package, so aggregators can filter on event_id, state or page. Anything
+// else keeps the default text output, which stays readable for local runs.
+func setupLogging() {
+	format := strings.ToLower(strings.TrimSpace(os.Getenv("LOG_FORMAT")))
+	switch format {
+	case "", "text":
+		return
+	case "json":
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{AddSource: true})))
+	default:
+		log.Printf("unknown LOG_FORMAT %q, using text", format)
+	}
+}
@@ -2,14 +2,20 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -42,19 +48,51 @@ type event struct {
 	Start struct {
 		Local string `json:"local"` // "YYYY-MM-DDTHH:MM:SS"
 	} `json:"start"`
-	Venue *struct {
-		Address struct {
-			Address1                string `json:"address_1"`
-			Address2                string `json:"address_2"`
-			City                    string `json:"city"`
-			Region                  string `json:"region"`
-			LocalizedAddressDisplay string `json:"localized_address_display"`
-			PostalCode              string `json:"postal_code"`
-		} `json:"address"`
-	} `json:"venue"`
-	TicketAvailability *struct {
-		HasAvailableTickets *bool `json:"has_available_tickets"`
-	} `json:"ticket_availability"`
+	Venue              *eventVenue         `json:"venue"`
+	TicketAvailability *ticketAvailability `json:"ticket_availability"`
+	IsFree             *bool               `json:"is_free"`
+	Category           *struct {
+		Name string `json:"name"`
+	} `json:"category"`
+	Subcategory *struct {
+		Name string `json:"name"`
+	} `json:"subcategory"`
+	Format *struct {
+		Name string `json:"name"`
+	} `json:"format"`
+	TicketClasses []struct {
+		QuantityTotal *int `json:"quantity_total"`
+		QuantitySold  *int `json:"quantity_sold"`
+	} `json:"ticket_classes"`
+	OnlineEvent bool `json:"online_event"`
+
+	// sourceURL keeps the EventBrite URL once URL has been rewritten for click tracking.
+	sourceURL string
+	// reminder marks a repeat notification sent as the event start approaches.
+	reminder bool
+	// claimed lists the Redis keys this run set while filtering the event,
+	// the only ones released again if it ends up not notified.
+	claimed []string
+	// test marks the synthetic INJECT_TEST_EVENT event.
+	test bool
+}
+
+type eventVenue struct {
+	Address struct {
+		Address1                string `json:"address_1"`
+		Address2                string `json:"address_2"`
+		City                    string `json:"city"`
+		Region                  string `json:"region"`
+		LocalizedAddressDisplay string `json:"localized_address_display"`
+		PostalCode              string `json:"postal_code"`
+	} `json:"address"`
+}
+
+type ticketAvailability struct {
+	HasAvailableTickets *bool `json:"has_available_tickets"`
+	EndSalesDate        *struct {
+		UTC string `json:"utc"` // "YYYY-MM-DDTHH:MM:SSZ"
+	} `json:"end_sales_date"`
 }
 
 func init() {
@@ -103,6 +141,30 @@ func envDurationHours(key string, defaultVal time.Duration) time.Duration {
 	return time.Duration(h) * time.Hour
 }
 
+func envDurationSeconds(key string, defaultVal time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return defaultVal
+	}
+	secs, err := strconv.Atoi(v)
+	if err != nil || secs <= 0 {
+		return defaultVal
+	}
+	return time.Duration(secs) * time.Second
+}
+
+func envInt(key string, defaultVal int) int {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return defaultVal
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return defaultVal
+	}
+	return n
+}
+
 func parseEventStart(e event) (time.Time, bool) {
 	if len(e.Start.Local) < len("2006-01-02T15:04:05") {
 		return time.Time{}, false
@@ -114,16 +176,187 @@ func parseEventStart(e event) (time.Time, bool) {
 	return t, true
 }
 
+// parseSalesEnd returns when ticket sales close, if EventBrite reported it.
+func parseSalesEnd(e event) (time.Time, bool) {
+	if e.TicketAvailability == nil || e.TicketAvailability.EndSalesDate == nil {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(e.TicketAvailability.EndSalesDate.UTC))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+const (
+	priceFilterAll  = "all"
+	priceFilterFree = "free"
+	priceFilterPaid = "paid"
+)
+
+type filterConfig struct {
+	priceFilter string
+	// collapseKey groups recurring events; empty disables collapsing.
+	collapseKey string
+	// keywords and denyKeywords are lowercase substrings matched against the event name.
+	keywords     []string
+	denyKeywords []string
+	// skipSalesEnded drops events whose ticket sales have already closed.
+	skipSalesEnded bool
+}
+
+func buildFilterConfig() filterConfig {
+	cfg := filterConfig{priceFilter: priceFilterAll, skipSalesEnded: envBool("SKIP_SALES_ENDED", true)}
+	if envBool("COLLAPSE_RECURRING", false) {
+		cfg.collapseKey = strings.ToLower(strings.TrimSpace(os.Getenv("COLLAPSE_RECURRING_KEY")))
+		switch cfg.collapseKey {
+		case collapseKeyName, collapseKeyNameVenue:
+		default:
+			if cfg.collapseKey != "" {
+				log.Printf("unknown COLLAPSE_RECURRING_KEY %q, defaulting to %s", cfg.collapseKey, collapseKeyNameVenue)
+			}
+			cfg.collapseKey = collapseKeyNameVenue
+		}
+	}
+
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("EVENT_PRICE_FILTER")))
+	switch v {
+	case "", priceFilterAll:
+	case priceFilterFree, priceFilterPaid:
+		cfg.priceFilter = v
+	default:
+		log.Printf("unknown EVENT_PRICE_FILTER %q, defaulting to %s", v, priceFilterAll)
+	}
+
+	cfg.keywords = parseKeywords(os.Getenv("NOTIFY_KEYWORDS"))
+	cfg.denyKeywords = parseKeywords(os.Getenv("NOTIFY_KEYWORDS_DENY"))
+	return cfg
+}
+
+func parseKeywords(raw string) []string {
+	return splitList(strings.ToLower(raw))
+}
+
+// splitList splits a comma-separated env value, trimming and dropping blanks.
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// matchesKeywords reports whether the event name contains any allowed keyword
+// (or no allowlist is set) and none of the denied ones, case-insensitively.
+func matchesKeywords(name string, keywords, denyKeywords []string) bool {
+	name = strings.ToLower(name)
+	for _, k := range denyKeywords {
+		if strings.Contains(name, k) {
+			return false
+		}
+	}
+	if len(keywords) == 0 {
+		return true
+	}
+	for _, k := range keywords {
+		if strings.Contains(name, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPriceFilter reports whether the event passes the configured price filter.
+// Events with unknown pricing always pass.
+func matchesPriceFilter(e event, priceFilter string) bool {
+	if e.IsFree == nil {
+		return true
+	}
+	switch priceFilter {
+	case priceFilterFree:
+		return *e.IsFree
+	case priceFilterPaid:
+		return !*e.IsFree
+	default:
+		return true
+	}
+}
+
+const (
+	filteredSalesEnded = "sales_ended"
+	filteredPrice      = "price"
+	filteredKeyword    = "keyword"
+)
+
+// filteredBy reports which configured filter drops an available upcoming
+// event, or "" when it passes them all.
+func filteredBy(e event, cfg filterConfig, now time.Time) string {
+	if cfg.skipSalesEnded {
+		if salesEnd, ok := parseSalesEnd(e); ok && salesEnd.Before(now) {
+			return filteredSalesEnded
+		}
+	}
+	if !matchesPriceFilter(e, cfg.priceFilter) {
+		return filteredPrice
+	}
+	if !matchesKeywords(e.Name.Text, cfg.keywords, cfg.denyKeywords) {
+		return filteredKeyword
+	}
+	return ""
+}
+
 type dedupeConfig struct {
 	ttlCap           time.Duration
 	reminderCooldown time.Duration
 	deleteOnSoldOut  bool
 	extraBuffer      time.Duration
 	minTTL           time.Duration
+	// catchup notifies every eligible event while still refreshing dedupe
+	// keys; set for one run by CATCHUP.
+	catchup bool
+	// jsonValue stores a dedupeRecord instead of the legacy "1" marker.
+	jsonValue bool
+	// ttlJitter adds up to this much random time to each TTL so keys for
+	// events sharing a start time don't all expire together.
+	ttlJitter time.Duration
+	// jitterInt63n is the random source for ttlJitter; nil uses math/rand.
+	jitterInt63n func(n int64) int64
+	// byURL also dedupes on the normalized event URL, catching reposts under a new ID.
+	byURL bool
+	// reminderWindow is how close to the start an announced event may be
+	// re-sent as a reminder once reminderCooldown has lapsed.
+	reminderWindow time.Duration
+	// notifyOnRestock keeps sold-out events' keys, flagged in the JSON value,
+	// and renotifies only on a sold-out to available transition.
+	notifyOnRestock bool
+	// analyze evaluates dedupe read-only (DEDUP_ANALYZE): no key is written
+	// or deleted and the run stops after reporting what would notify.
+	analyze bool
+}
+
+// defaultRedisKeyPrefix namespaces every key this service writes.
+const defaultRedisKeyPrefix = "lot:"
+
+// redisKeyPrefix is set from REDIS_KEY_PREFIX at startup so staging and
+// production can share one Redis instance without colliding.
+var redisKeyPrefix = defaultRedisKeyPrefix
+
+func loadRedisKeyPrefix() string {
+	prefix := strings.TrimSpace(os.Getenv("REDIS_KEY_PREFIX"))
+	if prefix == "" {
+		return defaultRedisKeyPrefix
+	}
+	return prefix
+}
+
+func eventKeyPrefix(eventID string) string {
+	return redisKeyPrefix + "event:" + eventID + ":"
 }
 
 func dedupeKey(eventID string) string {
-	return "lot:event:" + eventID + ":notified"
+	return eventKeyPrefix(eventID) + "notified"
 }
 
 func dedupeTTL(start time.Time, hasStart bool, cfg dedupeConfig) time.Duration {
@@ -133,9 +366,16 @@ func dedupeTTL(start time.Time, hasStart bool, cfg dedupeConfig) time.Duration {
 		if ttl < cfg.minTTL {
 			ttl = cfg.minTTL
 		}
-		if ttl > cfg.ttlCap {
-			ttl = cfg.ttlCap
+	}
+	if cfg.ttlJitter > 0 {
+		int63n := cfg.jitterInt63n
+		if int63n == nil {
+			int63n = rand.Int63n
 		}
+		ttl += time.Duration(int63n(int64(cfg.ttlJitter) + 1))
+	}
+	if ttl > cfg.ttlCap {
+		ttl = cfg.ttlCap
 	}
 	if cfg.reminderCooldown > 0 && ttl > cfg.reminderCooldown {
 		ttl = cfg.reminderCooldown
@@ -151,9 +391,23 @@ func newRedisClient(isLocal bool) *redis.Client {
 		}
 		return nil
 	}
-	password := os.Getenv("REDIS_PASSWORD")
-	log.Printf("redis dedupe enabled at %s", addr)
-	return redis.NewClient(&redis.Options{Addr: addr, Password: password})
+	opts := &redis.Options{
+		Addr:     addr,
+		Username: strings.TrimSpace(os.Getenv("REDIS_USERNAME")),
+		Password: os.Getenv("REDIS_PASSWORD"),
+	}
+	if envBool("REDIS_TLS", false) {
+		skipVerify := envBool("REDIS_TLS_SKIP_VERIFY", false)
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: skipVerify}
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			opts.TLSConfig.ServerName = host
+		}
+		if skipVerify {
+			log.Printf("redis TLS certificate verification disabled via REDIS_TLS_SKIP_VERIFY")
+		}
+	}
+	log.Printf("redis dedupe enabled at %s (tls=%t acl_user=%t)", addr, opts.TLSConfig != nil, opts.Username != "")
+	return redis.NewClient(opts)
 }
 
 // retryRedisConnection attempts to establish and verify a Redis connection with extensive retries
@@ -187,27 +441,47 @@ func retryRedisConnection(ctx context.Context, redisClient *redis.Client, maxAtt
 	return nil, fmt.Errorf("redis connection failed after %d attempts", maxAttempts)
 }
 
-func fetchPage(ctx context.Context, client *http.Client, orgID, token string, page int, m *metrics.Metrics) ([]event, int, error) {
+// exitCodeAuthError is both the process exit code and the healthchecks
+// exit-status suffix used when EventBrite rejects the token.
+const exitCodeAuthError = 2
+
+// AuthError reports that EventBrite rejected the API token (401/403).
+type AuthError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("eventbrite auth failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+func fetchPage(ctx context.Context, client *http.Client, orgID, token string, page int, fetchCfg fetchConfig, m *metrics.Metrics) ([]event, int, error) {
+	query := "status=live"
+	if fetchCfg.status != "" {
+		// Other statuses only matter for events that have not happened yet.
+		query = "status=" + fetchCfg.status + "&time_filter=current_future"
+	}
 	url := fmt.Sprintf(
-		"https://www.eventbriteapi.com/v3/organizers/%s/events/?status=live&expand=venue,ticket_availability&page=%d",
-		orgID, page,
+		"https://www.eventbriteapi.com/v3/organizers/%s/events/?%s&expand=venue,ticket_availability,ticket_classes,category,subcategory,format&page=%d",
+		orgID, query, page,
 	)
-	log.Printf("fetching page %d from EventBrite", page)
+	slog.Info("fetching EventBrite page", "organizer_id", orgID, "page", page)
 	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
 	req.Header.Set("Authorization", "Bearer "+token)
 
 	var resp *http.Response
 	var err error
+	var elapsed time.Duration
 	maxRetries := 4
+	rateLimitWaits := 0
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		if err := ctx.Err(); err != nil {
 			return nil, 0, err
 		}
 		startTime := time.Now()
 		resp, err = client.Do(req)
-		elapsed := time.Since(startTime)
-		log.Printf("EventBrite request attempt %d for page %d took %v", attempt, page, elapsed)
-		m.RecordEventBriteFetchPageDuration(elapsed)
+		elapsed = time.Since(startTime)
+		slog.Info("EventBrite request attempt finished", "page", page, "attempt", attempt, "elapsed_ms", elapsed.Milliseconds())
 
 		if err == nil {
 			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
@@ -215,10 +489,32 @@ func fetchPage(ctx context.Context, client *http.Client, orgID, token string, pa
 			}
 			body, _ := io.ReadAll(resp.Body)
 			resp.Body.Close()
+			// Throttling waits as long as EventBrite asks and does not use up the
+			// regular retry attempts, up to its own limit.
+			if resp.StatusCode == http.StatusTooManyRequests && rateLimitWaits < fetchCfg.rateLimitRetries {
+				rateLimitWaits++
+				waitTime := eventBriteRetryAfter(resp.Header.Get("Retry-After"), rateLimitWaits)
+				slog.Warn("EventBrite rate limited, retrying", "page", page, "wait", rateLimitWaits, "max_waits", fetchCfg.rateLimitRetries, "retry_in_ms", waitTime.Milliseconds())
+				m.RecordEventBriteRateLimitWait(waitTime)
+				select {
+				case <-ctx.Done():
+					return nil, 0, ctx.Err()
+				case <-time.After(waitTime):
+				}
+				attempt--
+				continue
+			}
+			if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+				authErr := &AuthError{StatusCode: resp.StatusCode, Body: string(body)}
+				slog.Error("EventBrite rejected the token", "page", page, "error", authErr)
+				m.RecordEventBriteAuthError()
+				m.RecordEventBriteFetch(0, authErr)
+				return nil, 0, authErr
+			}
 			err = fmt.Errorf("eventbrite status %d: %s", resp.StatusCode, string(body))
 
 			if resp.StatusCode != 429 && (resp.StatusCode >= 400 && resp.StatusCode < 500) {
-				log.Printf("permanent error from EventBrite for page %d: %v", page, err)
+				slog.Error("permanent error from EventBrite", "page", page, "attempt", attempt, "error", err)
 				m.RecordEventBriteFetch(0, err)
 				return nil, 0, err
 			}
@@ -226,89 +522,319 @@ func fetchPage(ctx context.Context, client *http.Client, orgID, token string, pa
 
 		if attempt < maxRetries {
 			waitTime := time.Duration(1<<uint(attempt-1)) * time.Second
-			log.Printf("error making request to EventBrite for page %d (attempt %d): %v, retrying in %v", page, attempt, err, waitTime)
-			
+			slog.Warn("EventBrite request failed, retrying", "page", page, "attempt", attempt, "error", err, "retry_in_ms", waitTime.Milliseconds())
+
 			select {
 			case <-ctx.Done():
 				return nil, 0, ctx.Err()
 			case <-time.After(waitTime):
 			}
 		} else {
-			log.Printf("error making request to EventBrite for page %d after %d attempts: %v", page, maxRetries, err)
+			slog.Error("EventBrite request failed, giving up", "page", page, "attempt", attempt, "error", err)
 			m.RecordEventBriteFetch(0, err)
 			return nil, 0, err
 		}
 	}
 
+	// Duration and page count are recorded once per page, for the attempt that succeeded.
+	m.RecordEventBriteFetchPageDuration(elapsed)
+
 	body, err := io.ReadAll(resp.Body)
 	resp.Body.Close()
 	if err != nil {
-		log.Printf("error reading EventBrite response body for page %d: %v", page, err)
+		slog.Error("error reading EventBrite response body", "page", page, "error", err)
 		return nil, 0, err
 	}
 
 	var r ebResp
 	if err := json.Unmarshal(body, &r); err != nil {
-		log.Printf("error parsing EventBrite response for page %d: %v", page, err)
+		slog.Error("error parsing EventBrite response", "page", page, "error", err)
 		return nil, 0, err
 	}
 
 	return r.Events, r.Pagination.PageCount, nil
 }
 
-func fetchAllLiveEvents(ctx context.Context, client *http.Client, orgID, token string, m *metrics.Metrics) ([]event, error) {
-	log.Printf("starting to fetch live events from EventBrite for organizer %s", orgID)
-	
-	firstPageEvents, pageCount, err := fetchPage(ctx, client, orgID, token, 1, m)
+// fetchConfig tunes how EventBrite pages are fetched.
+type fetchConfig struct {
+	// concurrency bounds how many pages after the first are fetched at once.
+	concurrency int
+	// pageDelay is the minimum spacing between requests made by the same worker.
+	pageDelay time.Duration
+	// rateLimitRetries is how many 429 responses a single page may wait out.
+	rateLimitRetries int
+	// status overrides the "live" EventBrite status filter, e.g. "canceled".
+	status string
+	// maxEvents stops fetching once this many events are held; 0 is unlimited.
+	maxEvents int
+}
+
+// maxEventBriteRateLimitWait caps a single Retry-After wait so one throttled
+// page cannot stall the run indefinitely.
+const maxEventBriteRateLimitWait = 2 * time.Minute
+
+// eventBriteRetryAfter returns how long to wait after a 429, honoring
+// Retry-After as either seconds or an HTTP date and otherwise backing off
+// exponentially from one second.
+func eventBriteRetryAfter(header string, wait int) time.Duration {
+	d := time.Duration(1<<uint(wait-1)) * time.Second
+	header = strings.TrimSpace(header)
+	if secs, err := strconv.Atoi(header); err == nil && secs >= 0 {
+		d = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(header); err == nil {
+		d = time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+	}
+	if d > maxEventBriteRateLimitWait {
+		d = maxEventBriteRateLimitWait
+	}
+	return d
+}
+
+func buildFetchConfig() fetchConfig {
+	return fetchConfig{
+		concurrency:      envInt("EVENTBRITE_FETCH_CONCURRENCY", 4),
+		pageDelay:        time.Duration(envInt("EVENTBRITE_PAGE_DELAY_MS", 0)) * time.Millisecond,
+		rateLimitRetries: envInt("EVENTBRITE_RATE_LIMIT_RETRIES", 5),
+		maxEvents:        envInt("EVENTBRITE_MAX_EVENTS", 0),
+	}
+}
+
+// fetchAllLiveEvents fetches page 1 to learn the page count, then fans the
+// remaining pages out to a bounded worker pool. Results are reassembled in
+// page order and deduplicated by event ID. The first page error cancels the
+// remaining fetches and is returned. With maxEvents set, no further pages are
+// requested once that many events have arrived and the result is truncated.
+func fetchAllLiveEvents(ctx context.Context, client *http.Client, orgID, token string, fetchCfg fetchConfig, m *metrics.Metrics) ([]event, error) {
+	slog.Info("starting to fetch live events from EventBrite", "organizer_id", orgID)
+
+	firstPageEvents, pageCount, err := fetchPage(ctx, client, orgID, token, 1, fetchCfg, m)
 	if err != nil {
 		return nil, err
 	}
-	
-	log.Printf("fetched %d events from page 1, total pages: %d", len(firstPageEvents), pageCount)
-	all := firstPageEvents
+
+	slog.Info("fetched EventBrite page", "organizer_id", orgID, "page", 1, "events", len(firstPageEvents), "page_count", pageCount)
+	if pageCount < 1 {
+		pageCount = 1
+	}
+	pages := make([][]event, pageCount+1)
+	pages[1] = firstPageEvents
 
 	if pageCount > 1 {
-		var mu sync.Mutex
+		workers := fetchCfg.concurrency
+		if workers < 1 {
+			workers = 1
+		}
+		if workers > pageCount-1 {
+			workers = pageCount - 1
+		}
+
+		fetchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var firstErr error
+		var errOnce sync.Once
 		var wg sync.WaitGroup
-		errs := make(chan error, pageCount-1)
+		jobs := make(chan int)
+
+		var fetchedMu sync.Mutex
+		fetched := len(firstPageEvents)
+		capReached := make(chan struct{})
+		var capOnce sync.Once
+		if fetchCfg.maxEvents > 0 && fetched >= fetchCfg.maxEvents {
+			close(capReached)
+		}
 
-		for p := 2; p <= pageCount; p++ {
+		for w := 0; w < workers; w++ {
 			wg.Add(1)
-			go func(page int) {
+			go func() {
 				defer wg.Done()
-				events, _, fetchErr := fetchPage(ctx, client, orgID, token, page, m)
-				if fetchErr != nil {
-					errs <- fetchErr
-					return
+				lastStart := time.Now()
+				for page := range jobs {
+					if fetchCfg.pageDelay > 0 {
+						select {
+						case <-fetchCtx.Done():
+							continue
+						case <-time.After(time.Until(lastStart.Add(fetchCfg.pageDelay))):
+						}
+					}
+					lastStart = time.Now()
+					events, _, fetchErr := fetchPage(fetchCtx, client, orgID, token, page, fetchCfg, m)
+					if fetchErr != nil {
+						errOnce.Do(func() {
+							firstErr = fetchErr
+							cancel()
+						})
+						continue
+					}
+					pages[page] = events
+					slog.Info("fetched EventBrite page", "organizer_id", orgID, "page", page, "events", len(events))
+					if fetchCfg.maxEvents > 0 {
+						fetchedMu.Lock()
+						fetched += len(events)
+						if fetched >= fetchCfg.maxEvents {
+							capOnce.Do(func() { close(capReached) })
+						}
+						fetchedMu.Unlock()
+					}
 				}
-				mu.Lock()
-				all = append(all, events...)
-				mu.Unlock()
-				log.Printf("fetched %d events from page %d", len(events), page)
-			}(p)
+			}()
+		}
+
+	feed:
+		for p := 2; p <= pageCount; p++ {
+			select {
+			case <-capReached:
+				slog.Warn("EVENTBRITE_MAX_EVENTS reached, not fetching remaining pages", "organizer_id", orgID, "max_events", fetchCfg.maxEvents, "first_skipped_page", p, "page_count", pageCount)
+				break feed
+			default:
+			}
+			select {
+			case jobs <- p:
+			case <-fetchCtx.Done():
+				break feed
+			}
 		}
-		
+		close(jobs)
 		wg.Wait()
-		close(errs)
 
-		if len(errs) > 0 {
-			return nil, <-errs // Return the first error encountered
+		if firstErr != nil {
+			return nil, firstErr
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := make(map[string]bool)
+	var all []event
+	for _, pageEvents := range pages {
+		for _, e := range pageEvents {
+			if seen[e.ID] {
+				continue
+			}
+			seen[e.ID] = true
+			all = append(all, e)
 		}
 	}
 
-	log.Printf("successfully fetched all %d live events", len(all))
+	if fetchCfg.maxEvents > 0 && len(all) > fetchCfg.maxEvents {
+		slog.Warn("keeping only the first EVENTBRITE_MAX_EVENTS events", "organizer_id", orgID, "max_events", fetchCfg.maxEvents, "fetched", len(all))
+		all = all[:fetchCfg.maxEvents]
+	}
+
+	slog.Info("successfully fetched all live events", "organizer_id", orgID, "events", len(all))
 	return all, nil
 }
 
+// fetchAllOrganizers fetches every organizer's live events and merges them,
+// deduplicated by event ID. A failing organizer is logged and skipped; an
+// error is returned only when every organizer failed.
+func fetchAllOrganizers(ctx context.Context, client *http.Client, orgIDs []string, token string, fetchCfg fetchConfig, m *metrics.Metrics) ([]event, error) {
+	if len(orgIDs) == 1 {
+		return fetchAllLiveEvents(ctx, client, orgIDs[0], token, fetchCfg, m)
+	}
+
+	seen := make(map[string]bool)
+	var all []event
+	var errs []error
+	for i, orgID := range orgIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		orgCfg := fetchCfg
+		if fetchCfg.maxEvents > 0 {
+			if len(all) >= fetchCfg.maxEvents {
+				slog.Warn("EVENTBRITE_MAX_EVENTS reached, skipping remaining organizers", "stage", "fetch", "max_events", fetchCfg.maxEvents, "skipped_organizers", len(orgIDs)-i)
+				break
+			}
+			orgCfg.maxEvents = fetchCfg.maxEvents - len(all)
+		}
+		events, err := fetchAllLiveEvents(ctx, client, orgID, token, orgCfg, m)
+		if err != nil {
+			slog.Error("failed to fetch events for organizer, continuing with the rest", "stage", "fetch", "organizer_id", orgID, "error", err)
+			m.RecordEventBriteOrganizerError()
+			errs = append(errs, fmt.Errorf("organizer %s: %w", orgID, err))
+			continue
+		}
+		for _, e := range events {
+			if seen[e.ID] {
+				continue
+			}
+			seen[e.ID] = true
+			all = append(all, e)
+		}
+	}
+
+	if len(errs) == len(orgIDs) {
+		return nil, errors.Join(errs...)
+	}
+	slog.Info("fetched live events across organizers", "stage", "fetch", "events", len(all), "organizers", len(orgIDs), "failed", len(errs))
+	return all, nil
+}
+
+// parseOrganizerIDs splits a comma-separated EVENTBRITE_ORGANIZER_ID, dropping
+// blanks and repeats.
+func parseOrganizerIDs(raw string) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, id := range strings.Split(raw, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 type appConfig struct {
 	isLocal             bool
-	orgID               string
+	orgIDs              []string
 	token               string
-	ntfyTopicURL        string
-	ntfyToken           string
-	discordEnabled      bool
-	discordWebhookURL   string
 	healthchecksPingURL string
+	digestMode          bool
+	digestMaxItems      int
+	filter              filterConfig
+	message             messageConfig
+	softRunBudget       time.Duration
+	injectTestEvent     bool
+	fetch               fetchConfig
+	clickTracking       clickTrackingConfig
+	runReportPath       string
+	outbound            outboundConfig
+	tagField            string
+	routes              []notifierRoute
+	notifyCancellations bool
+	validateOrganizer   bool
+	runLock             runLockConfig
+	dedupeAnalyze       bool
+	redisFailOnMisconf  bool
+	// dedupe is read once at startup; initRedis only applies it once Redis
+	// is reachable.
+	dedupe dedupeConfig
+	// catchup is CATCHUP, meant for a single ad-hoc Job after an outage.
+	catchup bool
+	// notify selects the notification destinations; only filled in
+	// production, since local runs print to stdout.
+	notify notifications.Config
+	// emptyRunsAlert flags the run on healthchecks once this many successful
+	// runs in a row sent nothing; 0 disables it.
+	emptyRunsAlert int
+	// emptyRunsPingURL is a separate check pinged with the empty-run status;
+	// when unset the main check gets a "log" ping instead.
+	emptyRunsPingURL string
+	// redisDial builds the Redis client; nil uses newRedisClient.
+	redisDial func(isLocal bool) *redis.Client
+}
+
+// messageConfig controls how event messages are rendered.
+type messageConfig struct {
+	locale            string
+	capacityThreshold int
 }
 
 func logModeAndSleep(isLocal bool) {
@@ -324,41 +850,138 @@ func logModeAndSleep(isLocal bool) {
 
 func loadConfig(isLocal bool) appConfig {
 	cfg := appConfig{isLocal: isLocal}
+	defer func() { logConfig(cfg) }()
 	log.Printf("loading configuration from environment variables (isLocal=%t)", isLocal)
-	cfg.orgID = mustEnv("EVENTBRITE_ORGANIZER_ID")
+	cfg.orgIDs = parseOrganizerIDs(mustEnv("EVENTBRITE_ORGANIZER_ID"))
+	if len(cfg.orgIDs) == 0 {
+		log.Fatalf("EVENTBRITE_ORGANIZER_ID has no organizer IDs")
+	}
 	cfg.token = mustEnv("EVENTBRITE_TOKEN")
-	log.Printf("loaded organizer ID: %s", cfg.orgID)
+	log.Printf("loaded organizer IDs: %s", strings.Join(cfg.orgIDs, ","))
+
+	cfg.validateOrganizer = envBool("VALIDATE_ORGANIZER", false)
+	cfg.dedupeAnalyze = envBool("DEDUP_ANALYZE", false)
+	cfg.dedupe = buildDedupeConfig()
+	cfg.redisFailOnMisconf = envBool("REDIS_FAIL_ON_MISCONFIG", false)
+	cfg.catchup = envBool("CATCHUP", false)
+	if cfg.dedupeAnalyze {
+		log.Printf("dedupe analyze mode: reporting would-notify/would-dedupe without writing to redis or notifying")
+	}
+	cfg.runLock = runLockConfig{
+		enabled: envBool("RUN_LOCK", false),
+		ttl:     envDurationSeconds("RUN_LOCK_TTL_SECONDS", defaultRunLockTTL),
+	}
+	if cfg.runLock.ttl <= 0 {
+		cfg.runLock.ttl = defaultRunLockTTL
+	}
+	cfg.fetch = buildFetchConfig()
+	cfg.outbound = buildOutboundConfig()
+	log.Printf("EventBrite fetch concurrency: %d, page delay: %v", cfg.fetch.concurrency, cfg.fetch.pageDelay)
 
 	cfg.healthchecksPingURL = strings.TrimSpace(os.Getenv("HEALTHCHECKS_PING_URL"))
 	if cfg.healthchecksPingURL != "" {
 		log.Printf("healthchecks ping URL configured")
 	}
+	cfg.emptyRunsAlert = envInt("EMPTY_RUNS_ALERT_THRESHOLD", 0)
+	cfg.emptyRunsPingURL = strings.TrimSpace(os.Getenv("HEALTHCHECKS_EMPTY_RUNS_PING_URL"))
+
+	cfg.filter = buildFilterConfig()
+	log.Printf("event price filter: %s", cfg.filter.priceFilter)
+
+	cfg.message = messageConfig{
+		locale:            normalizeLocale(os.Getenv("NOTIFY_LOCALE")),
+		capacityThreshold: envInt("CAPACITY_URGENCY_THRESHOLD", 0),
+	}
+	log.Printf("notification locale: %s, capacity urgency threshold: %d", cfg.message.locale, cfg.message.capacityThreshold)
+
+	cfg.softRunBudget = envDurationSeconds("SOFT_RUN_BUDGET_SECONDS", 0)
+	if cfg.softRunBudget > 0 {
+		log.Printf("soft run budget: %v", cfg.softRunBudget)
+	}
+
+	cfg.runReportPath = strings.TrimSpace(os.Getenv("RUN_REPORT_PATH"))
+
+	cfg.clickTracking = buildClickTrackingConfig()
+	if cfg.clickTracking.base != "" {
+		log.Printf("click tracking enabled via %s", cfg.clickTracking.base)
+	}
+
+	cfg.injectTestEvent = envBool("INJECT_TEST_EVENT", false)
+	if cfg.injectTestEvent {
+		log.Printf("synthetic test event injection enabled")
+	}
+
+	cfg.routes = buildNotifierRoutes()
+	if len(cfg.routes) > 0 {
+		log.Printf("notifier routing enabled (%d rules)", len(cfg.routes))
+	}
+
+	cfg.notifyCancellations = envBool("NOTIFY_CANCELLATIONS", false)
+	if cfg.notifyCancellations {
+		log.Printf("cancellation notifications enabled")
+	}
+
+	cfg.digestMode = envBool("DIGEST_MODE", false)
+	cfg.digestMaxItems = envInt("DIGEST_MAX_ITEMS", 10)
+	if cfg.digestMode {
+		log.Printf("digest mode enabled (maxItems=%d)", cfg.digestMaxItems)
+	}
 
 	if isLocal {
 		return cfg
 	}
 
-	cfg.ntfyTopicURL = mustEnv("NTFY_TOPIC_URL")
-	log.Printf("loaded ntfy topic URL: %s", cfg.ntfyTopicURL)
+	cfg.notify = notifications.ConfigFromEnv()
+	cfg.notify.NtfyTopicURLs = mustEnv("NTFY_TOPIC_URL")
+	log.Printf("loaded ntfy topic URL: %s", cfg.notify.NtfyTopicURLs)
 
 	// Token required for production, optional for local/docker-compose
-	isLocalNtfy := strings.Contains(cfg.ntfyTopicURL, "localhost") || strings.Contains(cfg.ntfyTopicURL, "ntfy:80")
+	isLocalNtfy := strings.Contains(cfg.notify.NtfyTopicURLs, "localhost") || strings.Contains(cfg.notify.NtfyTopicURLs, "ntfy:80")
 	if isLocalNtfy {
-		cfg.ntfyToken = strings.TrimSpace(os.Getenv("NTFY_TOKEN"))
-		if cfg.ntfyToken != "" {
+		if cfg.notify.NtfyToken != "" {
 			log.Printf("ntfy bearer token configured (localNtfy=%t)", isLocalNtfy)
 		} else {
 			log.Printf("ntfy bearer token not set (optional for local ntfy, localNtfy=%t)", isLocalNtfy)
 		}
 	} else {
-		cfg.ntfyToken = mustEnv("NTFY_TOKEN")
+		cfg.notify.NtfyToken = mustEnv("NTFY_TOKEN")
 		log.Printf("ntfy bearer token configured (localNtfy=%t)", isLocalNtfy)
 	}
 
-	cfg.discordEnabled = envBool("ENABLE_DISCORD_NOTIFIER", false)
-	if cfg.discordEnabled {
-		cfg.discordWebhookURL = mustEnv("DISCORD_WEBHOOK_URL")
+	if envBool("NTFY_TAG_TOPICS", false) {
+		cfg.tagField = strings.ToLower(strings.TrimSpace(os.Getenv("NTFY_TAG_FIELD")))
+		switch cfg.tagField {
+		case "":
+			cfg.tagField = tagFieldCategory
+		case tagFieldCategory, tagFieldSubcategory, tagFieldFormat:
+		default:
+			log.Printf("unknown NTFY_TAG_FIELD %q, defaulting to %s", cfg.tagField, tagFieldCategory)
+			cfg.tagField = tagFieldCategory
+		}
+		log.Printf("ntfy tag topics enabled (field=%s)", cfg.tagField)
+	}
+
+	// Setting DISCORD_WEBHOOK_URL is enough; ENABLE_DISCORD_NOTIFIER=false still turns it off.
+	if cfg.notify.DiscordWebhookURL != "" {
 		log.Printf("discord notifier enabled")
+	} else if envBool("ENABLE_DISCORD_NOTIFIER", false) {
+		mustEnv("DISCORD_WEBHOOK_URL")
+	}
+
+	if cfg.notify.WebhookURL != "" {
+		log.Printf("webhook notifier enabled")
+	}
+
+	if host := strings.TrimSpace(os.Getenv("SMTP_HOST")); host != "" {
+		cfg.notify.Email = notifications.EmailConfig{
+			Host:     host,
+			Port:     envInt("SMTP_PORT", 587),
+			Username: strings.TrimSpace(os.Getenv("SMTP_USERNAME")),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     mustEnv("SMTP_FROM"),
+			To:       splitList(mustEnv("SMTP_TO")),
+		}
+		log.Printf("email notifications enabled via %s:%d to %d recipients", cfg.notify.Email.Host, cfg.notify.Email.Port, len(cfg.notify.Email.To))
 	}
 
 	return cfg
@@ -402,54 +1025,283 @@ func pingHealthchecks(ctx context.Context, client *http.Client, baseURL, suffix
 	}
 }
 
-func runNotifier(ctx context.Context, httpClient *http.Client, cfg appConfig, isLocal bool, m *metrics.Metrics) error {
-	all, err := fetchAllLiveEvents(ctx, httpClient, cfg.orgID, cfg.token, m)
-	if err != nil {
-		return fmt.Errorf("failed to fetch events: %w", err)
+// pingEmptyRuns reports the empty-run streak once EMPTY_RUNS_ALERT_THRESHOLD
+// is set: a dedicated check is failed while the streak is at or above the
+// threshold and passed otherwise; without one, the main check gets a "log"
+// ping so the run stays up but is annotated.
+func pingEmptyRuns(ctx context.Context, client *http.Client, cfg appConfig, emptyRuns int64) {
+	if cfg.emptyRunsAlert <= 0 {
+		return
 	}
-	m.RecordEventsProcessed(len(all))
+	stuck := emptyRuns >= int64(cfg.emptyRunsAlert)
+	if stuck {
+		log.Printf("no notifications sent for %d consecutive runs (threshold %d), upstream may be stuck", emptyRuns, cfg.emptyRunsAlert)
+	}
+	if cfg.emptyRunsPingURL != "" {
+		suffix := ""
+		if stuck {
+			suffix = "fail"
+		}
+		pingHealthchecks(ctx, client, cfg.emptyRunsPingURL, suffix, 3)
+		return
+	}
+	if stuck {
+		pingHealthchecks(ctx, client, cfg.healthchecksPingURL, "log", 3)
+	}
+}
+
+// pingRunSuccess reports a successful run to healthchecks, with a "log"
+// ping first when the soft run budget cut it short.
+func pingRunSuccess(ctx context.Context, client *http.Client, cfg appConfig, summary runSummary) {
+	if summary.budgetSkipped > 0 {
+		// Partial success: leave a log entry on the check without flipping it to down.
+		pingHealthchecks(ctx, client, cfg.healthchecksPingURL, "log", 3)
+	}
+	pingEmptyRuns(ctx, client, cfg, summary.emptyRuns)
+	pingHealthchecks(ctx, client, cfg.healthchecksPingURL, "", 3)
+}
+
+// runSummary reports run outcomes that are not failures but still matter to main.
+type runSummary struct {
+	budgetSkipped int
+	// notified counts events delivered to at least one destination.
+	notified int
+	// emptyRuns is the streak of successful runs, including this one, that
+	// notified nothing; it stays 0 without Redis.
+	emptyRuns int64
+	// skippedLocked is set when another run held RUN_LOCK and nothing ran.
+	skippedLocked bool
+}
+
+// runLockConfig makes overlapping runs skip instead of racing each other.
+type runLockConfig struct {
+	enabled bool
+	ttl     time.Duration
+}
+
+func budgetExhausted(deadline time.Time) bool {
+	return !deadline.IsZero() && time.Now().After(deadline)
+}
 
-	redisClient, dedupeCfg := initRedis(ctx, isLocal, m)
+func runNotifier(ctx context.Context, httpClient *http.Client, cfg appConfig, isLocal bool, m *metrics.Metrics) (summary runSummary, err error) {
+	report := newRunReport(cfg.runReportPath, time.Now())
+	defer func() { report.write(summary, err) }()
+
+	var budgetDeadline time.Time
+	if cfg.softRunBudget > 0 {
+		budgetDeadline = time.Now().Add(cfg.softRunBudget)
+	}
+
+	redisClient, dedupeCfg := initRedis(ctx, isLocal, cfg.redisDial, cfg.dedupe, m)
+	if cfg.dedupeAnalyze {
+		if redisClient == nil {
+			slog.Warn("DEDUP_ANALYZE needs redis, nothing to analyze", "stage", "filter")
+			return summary, nil
+		}
+		dedupeCfg.analyze = true
+	}
+	if cfg.catchup && redisClient != nil && !dedupeCfg.analyze {
+		slog.Info("catch-up run: notifying every available event and rewriting its dedupe keys", "stage", "filter")
+		dedupeCfg.catchup = true
+	}
+	if cfg.runLock.enabled && !dedupeCfg.analyze {
+		if redisClient == nil {
+			slog.Warn("RUN_LOCK needs redis, proceeding without run lock")
+		} else {
+			release, ok := acquireRunLock(ctx, redisClient, cfg.runLock.ttl, m)
+			if !ok {
+				summary.skippedLocked = true
+				m.RecordRunSkippedLocked()
+				return summary, nil
+			}
+			defer release()
+		}
+	}
 	redisBroken := redisClient == nil && strings.TrimSpace(os.Getenv("REDIS_ADDR")) != ""
+	reconnector := newRedisReconnector(isLocal, redisClient, redisBroken, m)
+	recordTimeSinceLastSuccess(ctx, redisClient, time.Now(), m)
+	// redisClient is read when the deferred call runs, so a client restored
+	// by the reconnector during the notify loop is the one that persists.
+	defer func() {
+		if err == nil && !dedupeCfg.analyze {
+			persistLastSuccess(ctx, redisClient, time.Now(), m)
+			summary.emptyRuns = updateEmptyRunStreak(ctx, redisClient, summary.notified, m)
+		}
+	}()
+
+	if cfg.validateOrganizer {
+		if err := validateOrganizers(ctx, httpClient, cfg.orgIDs, cfg.token, m); err != nil {
+			return summary, fmt.Errorf("organizer validation failed: %w", err)
+		}
+	}
+
+	all, err := fetchAllOrganizers(ctx, httpClient, cfg.orgIDs, cfg.token, cfg.fetch, m)
+	if err != nil {
+		return summary, fmt.Errorf("failed to fetch events: %w", err)
+	}
+	if cfg.injectTestEvent {
+		testEvent := syntheticTestEvent(time.Now())
+		slog.Info("injecting synthetic test event", "event_id", testEvent.ID, "event_name", testEvent.Name.Text)
+		all = append(all, testEvent)
+	}
+	m.RecordEventsProcessed(len(all))
 
-	var primaryNotifier notifications.Notifier
-	var secondaryNotifiers []notifications.Notifier
+	var notifier *notifications.MultiNotifier
 	if !isLocal {
-		primaryNotifier, secondaryNotifiers = buildNotifiers(httpClient, cfg, m)
+		notifier = buildNotifiers(httpClient, cfg, m)
+	}
+	if cfg.notifyCancellations && !dedupeCfg.analyze {
+		if notifyCanceledEvents(ctx, httpClient, cfg, redisClient, dedupeCfg, notifier, m) && cfg.redisFailOnMisconf {
+			return summary, errors.New("redis misconfiguration detected while handling cancellations (REDIS_FAIL_ON_MISCONFIG)")
+		}
 	}
 
 	now := time.Now()
-	notifyEvents, availableCount := filterEvents(ctx, all, redisClient, dedupeCfg, now, m)
+	if cfg.filter.collapseKey != "" {
+		var collapsed int
+		all, collapsed = collapseRecurring(all, now, cfg.filter)
+		if collapsed > 0 {
+			slog.Info("collapsed recurring occurrences", "stage", "filter", "collapsed", collapsed, "key", cfg.filter.collapseKey)
+			m.RecordEventsRecurringCollapsed(collapsed)
+		}
+	}
+	if dedupeCfg.analyze {
+		wouldNotify, availableCount, _ := filterEvents(ctx, all, redisClient, dedupeCfg, cfg.filter, now, m, report)
+		m.RecordEventsAvailable(availableCount)
+		m.RecordEventsWouldNotify(len(wouldNotify))
+		report.setCounts(len(all), availableCount)
+		log.Printf("dedupe analyze: %d available, %d would notify (no redis keys written)", availableCount, len(wouldNotify))
+		return summary, nil
+	}
+	notifyEvents, availableCount, redisMisconfigured := filterEvents(ctx, all, redisClient, dedupeCfg, cfg.filter, now, m, report)
+	if cfg.redisFailOnMisconf && redisMisconfigured {
+		releaseDedupeKeys(ctx, redisClient, notifyEvents, m)
+		return summary, fmt.Errorf("redis misconfiguration detected while deduping, not notifying %d events (REDIS_FAIL_ON_MISCONFIG)", len(notifyEvents))
+	}
 	m.RecordEventsAvailable(availableCount)
+	report.setCounts(len(all), availableCount)
 
-	log.Printf("found %d events with available tickets (%d new)", availableCount, len(notifyEvents))
+	slog.Info("filtered events", "stage", "filter", "available", availableCount, "new", len(notifyEvents))
 	if len(notifyEvents) == 0 {
-		log.Printf("no new events to notify, exiting (availableCount=%d)", availableCount)
-		return nil
+		slog.Info("no new events to notify, exiting", "stage", "filter", "available", availableCount)
+		return summary, nil
 	}
+	notifyEvents = applyClickTracking(notifyEvents, cfg.clickTracking)
 
-	for _, e := range notifyEvents {
-		if err := ctx.Err(); err != nil {
-			return fmt.Errorf("notifier stopped early: %w", err)
+	defer func() {
+		if summary.budgetSkipped > 0 {
+			slog.Warn("soft run budget exhausted", "stage", "notify", "budget", cfg.softRunBudget, "skipped", summary.budgetSkipped)
+			m.RecordEventsBudgetSkipped(summary.budgetSkipped)
 		}
+	}()
 
-		if !redisBroken && redisClient == nil {
-			redisClient = ensureRedisForNotification(ctx, isLocal, redisClient, m)
-			if redisClient == nil {
-				redisBroken = true
+	if cfg.digestMode {
+		groups := groupEventsByState(notifyEvents)
+		var published []event
+		digestResults := make(map[string][]notifications.Result)
+		for gi, g := range groups {
+			if err := ctx.Err(); err != nil {
+				return summary, fmt.Errorf("notifier stopped early: %w", err)
+			}
+			if budgetExhausted(budgetDeadline) {
+				for _, rest := range groups[gi:] {
+					summary.budgetSkipped += len(rest.events)
+					releaseDedupeKeys(ctx, redisClient, rest.events, m)
+				}
+				break
+			}
+			published = append(published, g.events...)
+
+			if isLocal {
+				msg := formatDigestMessage(g.state, g.events, cfg.digestMaxItems, cfg.message)
+				slog.Info("local mode: printing digest to stdout", "stage", "notify", "state", g.state, "events", len(g.events), "bytes", len(msg))
+				log.Println(msg)
+				summary.notified += len(g.events)
+				for _, e := range g.events {
+					report.recordDelivery(e, localResults())
+				}
+				continue
 			}
+			for _, part := range splitDigestByRoute(g, notifier.Notifiers(), cfg.routes) {
+				msg := formatDigestMessage(g.state, part.digest.events, cfg.digestMaxItems, cfg.message)
+				partResults := publishDigestNotifications(ctx, part.notifiers, part.digest, msg)
+				for _, e := range part.digest.events {
+					digestResults[e.ID] = partResults
+				}
+			}
+		}
+		if isLocal || len(published) == 0 {
+			return summary, nil
+		}
+		batchResults := publishBatchNotifications(ctx, notifier.Notifiers(), published, cfg.message, cfg.tagField, cfg.routes)
+		for _, e := range published {
+			results := append(append([]notifications.Result(nil), digestResults[e.ID]...), batchResults[e.ID]...)
+			if len(deliveredNames(results)) > 0 {
+				summary.notified++
+				m.RecordEventNotified()
+				if e.reminder {
+					m.RecordReminderSent()
+				}
+			}
+			report.recordDelivery(e, results)
+		}
+		return summary, nil
+	}
+
+	for i, e := range notifyEvents {
+		if err := ctx.Err(); err != nil {
+			return summary, fmt.Errorf("notifier stopped early: %w", err)
+		}
+		if budgetExhausted(budgetDeadline) {
+			summary.budgetSkipped = len(notifyEvents) - i
+			releaseDedupeKeys(ctx, redisClient, notifyEvents[i:], m)
+			break
 		}
 
-		msg := formatEventMessage(e)
+		redisClient = reconnector.get(ctx)
+
+		msg := formatEventMessage(e, cfg.message)
 		if isLocal {
-			log.Printf("local mode: printing message to stdout (event=%s bytes=%d)", e.ID, len(msg))
+			slog.Info("local mode: printing message to stdout", "stage", "notify", "event_id", e.ID, "state", eventState(e), "bytes", len(msg))
 			log.Println(msg)
+			summary.notified++
+			report.recordDelivery(e, localResults())
 			continue
 		}
-		publishEventNotifications(ctx, primaryNotifier, secondaryNotifiers, e, msg, m)
+		routed := notifications.NewMultiNotifier(routeNotifiers(e, notifier.Notifiers(), cfg.routes)...)
+		if len(routed.Notifiers()) == 0 {
+			slog.Warn("notifier routes matched no configured notifier", "stage", "notify", "event_id", e.ID, "state", eventState(e), "event_name", e.Name.Text)
+		}
+		results := routed.NotifyAll(ctx, eventNotification(e, cfg.message, cfg.tagField))
+		if len(deliveredNames(results)) > 0 {
+			summary.notified++
+			m.RecordEventNotified()
+			if e.reminder {
+				m.RecordReminderSent()
+			}
+		}
+		recordDedupeDelivery(ctx, redisClient, dedupeCfg, e, msg, deliveredNames(results), m)
+		report.recordDelivery(e, results)
 	}
 
-	return nil
+	return summary, nil
+}
+
+// releaseDedupeKeys deletes the keys claimed during filtering for events
+// that were not notified, so the next run picks them up again. Keys that
+// existed before this run are left alone.
+func releaseDedupeKeys(ctx context.Context, redisClient *redis.Client, events []event, m *metrics.Metrics) {
+	if redisClient == nil {
+		return
+	}
+	for _, e := range events {
+		for _, redisKey := range e.claimed {
+			if err := redisClient.Del(ctx, redisKey).Err(); err != nil {
+				slog.Error("redis delete failed", "stage", "notify", "key", redisKey, "event_id", e.ID, "error", err)
+				recordRedisError(err, m)
+			}
+		}
+	}
 }
 
 func buildDedupeConfig() dedupeConfig {
@@ -459,6 +1311,11 @@ func buildDedupeConfig() dedupeConfig {
 		deleteOnSoldOut:  envBool("DEDUP_DELETE_ON_SOLD_OUT", true),
 		extraBuffer:      envDurationHours("DEDUP_EXTRA_BUFFER_HOURS", time.Hour),
 		minTTL:           envDurationHours("DEDUP_MIN_TTL_HOURS", time.Hour),
+		jsonValue:        strings.EqualFold(strings.TrimSpace(os.Getenv("DEDUP_VALUE_FORMAT")), "json"),
+		ttlJitter:        time.Duration(envInt("DEDUP_TTL_JITTER_MINUTES", 0)) * time.Minute,
+		byURL:            envBool("DEDUP_BY_URL", false),
+		reminderWindow:   envDurationHours("REMINDER_WINDOW_HOURS", 24*time.Hour),
+		notifyOnRestock:  envBool("DEDUP_NOTIFY_ON_RESTOCK", false),
 	}
 	if dedupeCfg.ttlCap <= 0 {
 		dedupeCfg.ttlCap = 14 * 24 * time.Hour
@@ -466,11 +1323,23 @@ func buildDedupeConfig() dedupeConfig {
 	if dedupeCfg.minTTL <= 0 {
 		dedupeCfg.minTTL = time.Hour
 	}
+	if dedupeCfg.notifyOnRestock && !dedupeCfg.jsonValue {
+		log.Printf("DEDUP_NOTIFY_ON_RESTOCK needs JSON dedupe values, using DEDUP_VALUE_FORMAT=json")
+		dedupeCfg.jsonValue = true
+	}
 	return dedupeCfg
 }
 
-func initRedis(ctx context.Context, isLocal bool, m *metrics.Metrics) (*redis.Client, dedupeConfig) {
-	redisClient := newRedisClient(isLocal)
+func initRedis(ctx context.Context, isLocal bool, dial func(isLocal bool) *redis.Client, dedupeCfg dedupeConfig, m *metrics.Metrics) (*redis.Client, dedupeConfig) {
+	if envBool("DEDUP_DISABLE", false) {
+		log.Printf("redis dedupe disabled via DEDUP_DISABLE, every eligible event will notify")
+		return nil, dedupeConfig{}
+	}
+
+	if dial == nil {
+		dial = newRedisClient
+	}
+	redisClient := dial(isLocal)
 	if redisClient == nil {
 		return nil, dedupeConfig{}
 	}
@@ -482,15 +1351,20 @@ func initRedis(ctx context.Context, isLocal bool, m *metrics.Metrics) (*redis.Cl
 		return nil, dedupeConfig{}
 	}
 
-	dedupeCfg := buildDedupeConfig()
-	log.Printf("redis dedupe config: maxTTL=%v reminderCooldown=%v deleteOnSoldOut=%v extraBuffer=%v minTTL=%v",
-		dedupeCfg.ttlCap, dedupeCfg.reminderCooldown, dedupeCfg.deleteOnSoldOut, dedupeCfg.extraBuffer, dedupeCfg.minTTL)
+	log.Printf("redis dedupe config: maxTTL=%v reminderCooldown=%v deleteOnSoldOut=%v extraBuffer=%v minTTL=%v jsonValue=%v ttlJitter=%v byURL=%v notifyOnRestock=%v",
+		dedupeCfg.ttlCap, dedupeCfg.reminderCooldown, dedupeCfg.deleteOnSoldOut, dedupeCfg.extraBuffer, dedupeCfg.minTTL, dedupeCfg.jsonValue, dedupeCfg.ttlJitter, dedupeCfg.byURL, dedupeCfg.notifyOnRestock)
 	return verifiedClient, dedupeCfg
 }
 
-func filterEvents(ctx context.Context, events []event, redisClient *redis.Client, dedupeCfg dedupeConfig, now time.Time, m *metrics.Metrics) ([]event, int) {
-	var notifyEvents []event
-	availableCount := 0
+// filterEvents returns the events to notify and how many were available.
+// misconfigured reports whether a Redis error seen while deduping pointed at
+// a misconfiguration rather than a passing failure.
+func filterEvents(ctx context.Context, events []event, redisClient *redis.Client, dedupeCfg dedupeConfig, filterCfg filterConfig, now time.Time, m *metrics.Metrics, report *runReport) (notifyEvents []event, availableCount int, misconfigured bool) {
+	noteRedisError := func(err error) {
+		if err != nil && classifyRedisError(err).misconfiguration() {
+			misconfigured = true
+		}
+	}
 
 	for _, e := range events {
 		redisKey := ""
@@ -498,16 +1372,37 @@ func filterEvents(ctx context.Context, events []event, redisClient *redis.Client
 			redisKey = dedupeKey(e.ID)
 		}
 
+		if e.test {
+			// The synthetic test event skips every filter and dedupe key so
+			// it always reaches the test destination.
+			availableCount++
+			notifyEvents = append(notifyEvents, e)
+			continue
+		}
+
 		available := isTicketsAvailable(e)
 		if !available {
 			m.RecordEventSoldOut()
-			if redisClient != nil && dedupeCfg.deleteOnSoldOut {
-				deleted, err := redisClient.Del(ctx, redisKey).Result()
-				if err != nil {
-					log.Printf("redis delete failed for %s (event %s): %v", redisKey, e.ID, err)
-					m.RecordRedisOperationError()
-				} else if deleted > 0 {
-					log.Printf("redis deleted key %s for sold-out event %s (%s)", redisKey, e.ID, e.Name.Text)
+			report.recordSoldOut()
+			if redisClient == nil || dedupeCfg.analyze {
+				continue
+			}
+			if dedupeCfg.notifyOnRestock {
+				noteRedisError(markDedupeSoldOut(ctx, redisClient, e, now, m))
+			} else if dedupeCfg.deleteOnSoldOut {
+				soldOutKeys := eventDedupeKeys(e, dedupeCfg)
+				if remindersEnabled(dedupeCfg) {
+					soldOutKeys = append(soldOutKeys, announcedKey(e.ID), remindedKey(e.ID))
+				}
+				for _, soldOutKey := range soldOutKeys {
+					deleted, err := deleteKey(ctx, redisClient, soldOutKey)
+					if err != nil {
+						slog.Error("redis delete failed", "stage", "dedupe", "key", soldOutKey, "event_id", e.ID, "error", err)
+						recordRedisError(err, m)
+						noteRedisError(err)
+					} else if deleted > 0 {
+						slog.Info("redis deleted key for sold-out event", "stage", "dedupe", "key", soldOutKey, "event_id", e.ID, "event_name", e.Name.Text)
+					}
 				}
 			}
 			continue
@@ -521,20 +1416,124 @@ func filterEvents(ctx context.Context, events []event, redisClient *redis.Client
 		if hasStart && startTime.Before(now) {
 			continue
 		}
+		switch filteredBy(e, filterCfg, now) {
+		case filteredSalesEnded:
+			m.RecordEventSalesEnded()
+			continue
+		case filteredPrice:
+			m.RecordEventPriceFiltered()
+			continue
+		case filteredKeyword:
+			m.RecordEventKeywordFiltered()
+			continue
+		}
 
 		shouldNotify := true
-		if redisClient != nil {
+		if redisClient != nil && dedupeCfg.analyze {
+			// DEDUP_ANALYZE reads the same keys without claiming any.
+			shouldNotify = analyzeDedupe(ctx, redisClient, e, startTime, hasStart, dedupeCfg, now, m)
+			if !shouldNotify {
+				m.RecordEventDeduplicated()
+				report.recordDeduplicated()
+			}
+		} else if redisClient != nil && dedupeCfg.catchup {
+			// Write the same keys a first notification would, so the next
+			// run dedupes (and reminds) as usual. Only keys this run created
+			// are claimed: releasing one that an earlier run wrote would make
+			// the next run re-notify.
+			ttl := dedupeTTL(startTime, hasStart, dedupeCfg)
+			setKey := func(key string, value any, ttl time.Duration) {
+				created, err := refreshKey(ctx, redisClient, key, value, ttl)
+				if err != nil {
+					slog.Error("redis set failed, proceeding to notify", "stage", "dedupe", "key", key, "event_id", e.ID, "error", err)
+					recordRedisError(err, m)
+					noteRedisError(err)
+					return
+				}
+				slog.Info("catch-up: refreshed dedupe key", "stage", "dedupe", "key", key, "ttl", ttl.String(), "created", created, "event_id", e.ID, "event_name", e.Name.Text)
+				if created {
+					e.claimed = append(e.claimed, key)
+				}
+			}
+			setKey(redisKey, dedupeValue(dedupeCfg, now), ttl)
+			for _, urlKey := range eventDedupeKeys(e, dedupeCfg)[1:] {
+				setKey(urlKey, e.ID, ttl)
+			}
+			if remindersEnabled(dedupeCfg) {
+				setKey(announcedKey(e.ID), "1", fullDedupeTTL(startTime, hasStart, dedupeCfg))
+			}
+		} else if redisClient != nil {
 			ttl := dedupeTTL(startTime, hasStart, dedupeCfg)
-			set, err := redisClient.SetNX(ctx, redisKey, "1", ttl).Result()
+			restocked := false
+			set, err := claimKey(ctx, redisClient, redisKey, dedupeValue(dedupeCfg, now), ttl)
 			if err != nil {
-				log.Printf("redis setnx failed for %s (event %s): %v (proceeding to notify)", redisKey, e.ID, err)
-				m.RecordRedisOperationError()
+				if recordRedisError(err, m) == redisErrorWrongType {
+					slog.Error("redis dedupe key collides with another writer, skipping event", "stage", "dedupe", "key", redisKey, "event_id", e.ID, "error", err)
+					shouldNotify = false
+				} else {
+					slog.Error("redis setnx failed, proceeding to notify", "stage", "dedupe", "key", redisKey, "event_id", e.ID, "error", err)
+				}
+				noteRedisError(err)
 			} else if set {
-				log.Printf("redis set key %s with TTL %v for event %s (%s)", redisKey, ttl, e.ID, e.Name.Text)
+				slog.Info("redis set dedupe key", "stage", "dedupe", "key", redisKey, "ttl", ttl.String(), "event_id", e.ID, "state", eventState(e), "event_name", e.Name.Text)
+				e.claimed = append(e.claimed, redisKey)
 			} else {
-				log.Printf("redis dedupe skip: key %s already exists for event %s (%s)", redisKey, e.ID, e.Name.Text)
-				shouldNotify = false
-				m.RecordEventDeduplicated()
+				if dedupeCfg.notifyOnRestock {
+					var err error
+					restocked, err = claimRestock(ctx, redisClient, e, now, m)
+					noteRedisError(err)
+					if restocked {
+						// Releasing the key on a failed send lets the next
+						// run notify the event again instead of losing it.
+						e.claimed = append(e.claimed, redisKey)
+					}
+				}
+				if !restocked {
+					slog.Info("redis dedupe skip: key already exists", "stage", "dedupe", "key", redisKey, "event_id", e.ID, "state", eventState(e), "event_name", e.Name.Text)
+					shouldNotify = false
+					m.RecordEventDeduplicated()
+					report.recordDeduplicated()
+				}
+			}
+			// The URL key holds the ID that claimed it, so a repost under a new
+			// ID is skipped even though its own ID key was just set.
+			for _, urlKey := range eventDedupeKeys(e, dedupeCfg)[1:] {
+				if !shouldNotify || restocked {
+					break
+				}
+				set, err := claimKey(ctx, redisClient, urlKey, e.ID, ttl)
+				if err != nil {
+					if recordRedisError(err, m) == redisErrorWrongType {
+						slog.Error("redis URL key collides with another writer, skipping event", "stage", "dedupe", "key", urlKey, "event_id", e.ID, "error", err)
+						shouldNotify = false
+					} else {
+						slog.Error("redis setnx failed, proceeding to notify", "stage", "dedupe", "key", urlKey, "event_id", e.ID, "error", err)
+					}
+					noteRedisError(err)
+				} else if set {
+					e.claimed = append(e.claimed, urlKey)
+				} else {
+					slog.Info("redis dedupe skip: URL key already exists", "stage", "dedupe", "key", urlKey, "event_id", e.ID, "state", eventState(e), "event_name", e.Name.Text)
+					shouldNotify = false
+					m.RecordEventDeduplicated()
+					report.recordDeduplicated()
+				}
+			}
+			if shouldNotify && !restocked && remindersEnabled(dedupeCfg) {
+				var err error
+				shouldNotify, e.reminder, err = classifyReminder(ctx, redisClient, e, startTime, hasStart, dedupeCfg, now, m)
+				noteRedisError(err)
+				if shouldNotify && err == nil {
+					if e.reminder {
+						e.claimed = append(e.claimed, remindedKey(e.ID))
+					} else {
+						e.claimed = append(e.claimed, announcedKey(e.ID))
+					}
+				}
+				if !shouldNotify {
+					m.RecordEventDeduplicated()
+					report.recordDeduplicated()
+				}
 			}
 		}
 
@@ -543,83 +1542,290 @@ func filterEvents(ctx context.Context, events []event, redisClient *redis.Client
 		}
 	}
 
-	return notifyEvents, availableCount
+	return notifyEvents, availableCount, misconfigured
 }
 
-func ensureRedisForNotification(ctx context.Context, isLocal bool, redisClient *redis.Client, m *metrics.Metrics) *redis.Client {
-	if redisClient != nil {
-		return redisClient
-	}
-	log.Printf("redis unavailable, attempting reconnection before sending notification")
+// ensureRedisForNotification makes one attempt to reconnect to Redis before
+// notifying, returning nil when Redis is still unavailable.
+func ensureRedisForNotification(ctx context.Context, isLocal bool, m *metrics.Metrics) *redis.Client {
+	slog.Warn("redis unavailable, attempting reconnection before sending notification", "stage", "notify")
 	tempClient := newRedisClient(isLocal)
 	if tempClient == nil {
-		log.Printf("redis still unavailable, skipping notification")
+		slog.Warn("redis still unavailable, skipping notification", "stage", "notify")
 		m.RecordRedisConnectionError()
 		return nil
 	}
 	verifiedClient, err := retryRedisConnection(ctx, tempClient, maxRedisAttempts, redisBaseDelay, m)
 	if err != nil {
-		log.Printf("redis reconnection failed, proceeding without dedupe: %v", err)
+		slog.Error("redis reconnection failed, proceeding without dedupe", "stage", "notify", "error", err)
 		return nil
 	}
-	log.Printf("redis connection restored; continuing with notifications")
+	slog.Info("redis connection restored, continuing with notifications", "stage", "notify")
 	return verifiedClient
 }
 
-func formatEventMessage(e event) string {
-	timeStr := ""
-	if t, ok := parseEventStart(e); ok {
-		timeStr = t.Format("Mon, Jan 2 at 15:04")
+// formatEventMessage renders e as plain text, the form used for local
+// output, digests and the delivery hash. Notifiers render their own format
+// from eventNotification.
+func formatEventMessage(e event, msgCfg messageConfig) string {
+	return notifications.PlainText(eventNotification(e, msgCfg, ""))
+}
+
+// remainingCapacity sums unsold tickets across ticket classes. It reports
+// false when no ticket classes were returned or any class lacks quantities.
+func remainingCapacity(e event) (int, bool) {
+	if len(e.TicketClasses) == 0 {
+		return 0, false
 	}
-	city := ""
-	if e.Venue != nil {
-		city = e.Venue.Address.City
+	remaining := 0
+	for _, tc := range e.TicketClasses {
+		if tc.QuantityTotal == nil || tc.QuantitySold == nil {
+			return 0, false
+		}
+		if left := *tc.QuantityTotal - *tc.QuantitySold; left > 0 {
+			remaining += left
+		}
+	}
+	return remaining, true
+}
+
+// capacityPhrase returns "only N left!" when the remaining capacity is known
+// and below threshold; a threshold of zero disables the phrase.
+func capacityPhrase(e event, threshold int) string {
+	if threshold <= 0 {
+		return ""
+	}
+	remaining, ok := remainingCapacity(e)
+	if !ok || remaining <= 0 || remaining >= threshold {
+		return ""
+	}
+	return fmt.Sprintf("only %d left!", remaining)
+}
+
+const (
+	tagFieldCategory    = "category"
+	tagFieldSubcategory = "subcategory"
+	tagFieldFormat      = "format"
+)
+
+// eventTag returns the name of the configured EventBrite taxonomy field, or
+// "" when tag topics are disabled or the event has no value for it.
+func eventTag(e event, field string) string {
+	switch {
+	case field == tagFieldCategory && e.Category != nil:
+		return strings.TrimSpace(e.Category.Name)
+	case field == tagFieldSubcategory && e.Subcategory != nil:
+		return strings.TrimSpace(e.Subcategory.Name)
+	case field == tagFieldFormat && e.Format != nil:
+		return strings.TrimSpace(e.Format.Name)
+	default:
+		return ""
 	}
-	return fmt.Sprintf("%s %s (%s) %s", city, e.Name.Text, timeStr, e.URL)
 }
 
-func buildNotifiers(httpClient *http.Client, cfg appConfig, m *metrics.Metrics) (notifications.Notifier, []notifications.Notifier) {
-	primary := notifications.NewNtfyNotifier(httpClient, cfg.ntfyTopicURL, cfg.ntfyToken, m)
-	var secondary []notifications.Notifier
-	if cfg.discordEnabled {
-		secondary = append(secondary, notifications.NewDiscordNotifier(httpClient, cfg.discordWebhookURL))
+func eventState(e event) string {
+	if e.Venue == nil {
+		return ""
 	}
-	return primary, secondary
+	return strings.TrimSpace(e.Venue.Address.Region)
 }
 
-func publishEventNotifications(ctx context.Context, primary notifications.Notifier, secondary []notifications.Notifier, e event, msg string, m *metrics.Metrics) {
-	state := ""
+// stateDigest holds the events of a single run that share a venue state.
+type stateDigest struct {
+	state  string
+	events []event
+}
+
+// groupEventsByState buckets events by venue state, sorting states
+// alphabetically and events within a state by start time.
+func groupEventsByState(events []event) []stateDigest {
+	byState := make(map[string][]event)
+	for _, e := range events {
+		state := eventState(e)
+		byState[state] = append(byState[state], e)
+	}
+
+	groups := make([]stateDigest, 0, len(byState))
+	for state, evs := range byState {
+		sort.SliceStable(evs, func(i, j int) bool {
+			ti, okI := parseEventStart(evs[i])
+			tj, okJ := parseEventStart(evs[j])
+			if okI != okJ {
+				return okI
+			}
+			return ti.Before(tj)
+		})
+		groups = append(groups, stateDigest{state: state, events: evs})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].state < groups[j].state })
+	return groups
+}
+
+func formatDigestMessage(state string, events []event, maxItems int, msgCfg messageConfig) string {
+	var b strings.Builder
+	if state != "" {
+		fmt.Fprintf(&b, "%d new events in %s", len(events), state)
+	} else {
+		fmt.Fprintf(&b, "%d new events", len(events))
+	}
+
+	shown := events
+	if maxItems > 0 && len(shown) > maxItems {
+		shown = shown[:maxItems]
+	}
+	for _, e := range shown {
+		b.WriteString("\n- ")
+		b.WriteString(formatEventMessage(e, msgCfg))
+	}
+	if rest := len(events) - len(shown); rest > 0 {
+		fmt.Fprintf(&b, "\n...and %d more", rest)
+	}
+	return b.String()
+}
+
+// buildNotifiers returns every configured destination behind one
+// MultiNotifier.
+func buildNotifiers(httpClient *http.Client, cfg appConfig, m *metrics.Metrics) *notifications.MultiNotifier {
+	return notifications.NewMultiNotifier(notifications.BuildNotifiers(httpClient, cfg.notify, m)...)
+}
+
+// eventNotification describes e in structured fields and leaves the body for
+// each notifier to render.
+func eventNotification(e event, msgCfg messageConfig, tagField string) notifications.Notification {
+	n := notifications.Notification{EventID: e.ID, State: eventState(e), URL: strings.TrimSpace(e.URL), Tag: eventTag(e, tagField), Name: e.Name.Text, Title: strings.TrimSpace(e.Name.Text), Test: e.test}
+	if e.Venue != nil {
+		n.City = e.Venue.Address.City
+	}
+	n.Tags = eventTags(e)
+	if t, ok := parseEventStart(e); ok {
+		n.Start = t
+		n.When = formatEventTime(t, msgCfg.locale)
+	}
+	n.Note = capacityPhrase(e, msgCfg.capacityThreshold)
+	if e.reminder {
+		n.Prefix = reminderPrefix(msgCfg.locale)
+	}
+	return n
+}
+
+// eventTags returns the ntfy tags for an event notification: tada for a
+// first announcement only, a pin when the venue is known, and the state for
+// filtering.
+func eventTags(e event) []string {
+	var tags []string
+	if !e.reminder {
+		tags = append(tags, "tada")
+	}
 	if e.Venue != nil {
-		state = e.Venue.Address.Region
+		tags = append(tags, "round_pushpin")
+	}
+	if state := notifications.TopicSlug(eventState(e)); state != "" {
+		tags = append(tags, state)
+	}
+	return tags
+}
+
+// publishDigestNotifications sends one state digest to every notifier that
+// cannot take a batch; batch-capable notifiers are handled by publishBatchNotifications.
+func publishDigestNotifications(ctx context.Context, notifiers []notifications.Notifier, g stateDigest, msg string) []notifications.Result {
+	ids := make([]string, 0, len(g.events))
+	for _, e := range g.events {
+		ids = append(ids, e.ID)
+	}
+	n := notifications.Notification{EventID: strings.Join(ids, ","), Body: msg, State: g.state}
+	if state := notifications.TopicSlug(g.state); state != "" {
+		n.Tags = []string{state}
 	}
-	n := notifications.Notification{EventID: e.ID, Body: msg, State: state, URL: strings.TrimSpace(e.URL)}
 
-	allNotifiers := append([]notifications.Notifier{primary}, secondary...)
-	
-	// Create a wait group to wait for all notifications for this event
 	var wg sync.WaitGroup
-	for _, notifier := range allNotifiers {
+	var mu sync.Mutex
+	var results []notifications.Result
+	for _, notifier := range notifiers {
+		if _, ok := notifier.(notifications.BatchNotifier); ok {
+			continue
+		}
 		wg.Add(1)
 		go func(ntf notifications.Notifier) {
 			defer wg.Done()
-			if err := ntf.Notify(ctx, n); err != nil {
-				log.Printf("failed to publish notification via %s for event %s: %v", ntf.Name(), e.ID, err)
-			} else if ntf.Name() == primary.Name() {
-				// Record metrics only for primary (or maybe all, but following existing pattern)
-				m.RecordEventNotified()
+			err := ntf.Notify(ctx, n)
+			mu.Lock()
+			results = append(results, notifications.Result{Notifier: ntf.Name(), Err: err})
+			mu.Unlock()
+			if err != nil {
+				slog.Error("failed to publish digest", "stage", "notify", "notifier", ntf.Name(), "state", g.state, "error", err)
 			}
 		}(notifier)
 	}
 	wg.Wait()
+	return results
+}
+
+// publishBatchNotifications posts the full list of a run's events to every
+// notifier that supports batching, in place of per-state digests. Each
+// notifier only gets the events routed to it; results are keyed by event ID.
+func publishBatchNotifications(ctx context.Context, notifiers []notifications.Notifier, events []event, msgCfg messageConfig, tagField string, routes []notifierRoute) map[string][]notifications.Result {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	results := make(map[string][]notifications.Result)
+	for _, notifier := range notifiers {
+		bn, ok := notifier.(notifications.BatchNotifier)
+		if !ok {
+			continue
+		}
+		var routed []event
+		var batch []notifications.Notification
+		for _, e := range events {
+			if len(routeNotifiers(e, []notifications.Notifier{notifier}, routes)) == 0 {
+				continue
+			}
+			routed = append(routed, e)
+			batch = append(batch, eventNotification(e, msgCfg, tagField))
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(ntf notifications.BatchNotifier) {
+			defer wg.Done()
+			errs := ntf.NotifyBatch(ctx, batch)
+			failed := 0
+			var lastErr error
+			mu.Lock()
+			for i, e := range routed {
+				results[e.ID] = append(results[e.ID], notifications.Result{Notifier: ntf.Name(), Err: errs[i]})
+				if errs[i] != nil {
+					failed++
+					lastErr = errs[i]
+				}
+			}
+			mu.Unlock()
+			if failed > 0 {
+				slog.Error("failed to publish batch", "stage", "notify", "notifier", ntf.Name(), "failed", failed, "events", len(batch), "error", lastErr)
+			}
+		}(bn)
+	}
+	wg.Wait()
+	return results
 }
 
 func main() {
+	inspectID := flag.String("inspect", "", "print the Redis dedupe state for an event ID and exit")
+	flag.Parse()
+	redisKeyPrefix = loadRedisKeyPrefix()
+	if *inspectID != "" {
+		os.Exit(runInspect(strings.TrimSpace(*inspectID)))
+	}
+
+	setupLogging()
 	log.Printf("starting lectures-notifier (pid=%d)", os.Getpid())
 	isLocal := os.Getenv("NTFY_TOPIC_URL") == ""
 	logModeAndSleep(isLocal)
 	cfg := loadConfig(isLocal)
-	httpClient := &http.Client{Timeout: 45 * time.Second}
-	metricsClient := metrics.InitializeMetricsFromEnv(isLocal)
+	httpClient, err := newHTTPClient(cfg.outbound, 45*time.Second)
+	if err != nil {
+		log.Fatalf("failed to build HTTP client: %v", err)
+	}
+	metricsClient := metrics.InitializeMetricsFromEnv(isLocal, httpClient)
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 	ctx, timeoutCancel := context.WithTimeout(ctx, 3*time.Minute)
@@ -632,6 +1838,7 @@ func main() {
 	metricsClient.RecordExecutionStart(ctx)
 
 	var runErr error
+	var summary runSummary
 	var panicVal interface{}
 
 	// Robust exit handler to catch panics/errors and push final execution status
@@ -649,6 +1856,20 @@ func main() {
 		if runErr != nil {
 			metricsClient.RecordExecutionFailure(reportCtx, duration, runErr.Error())
 			_ = metricsClient.Push(reportCtx)
+			_ = metricsClient.WriteTextfile()
+
+			var authErr *AuthError
+			if errors.As(runErr, &authErr) {
+				pingHealthchecks(reportCtx, httpClient, cfg.healthchecksPingURL, strconv.Itoa(exitCodeAuthError), 3)
+				log.Printf("notifier run failed: EventBrite rejected the token (status %d), check EVENTBRITE_TOKEN", authErr.StatusCode)
+				os.Exit(exitCodeAuthError)
+			}
+			var notFoundErr *OrganizerNotFoundError
+			if errors.As(runErr, &notFoundErr) {
+				pingHealthchecks(reportCtx, httpClient, cfg.healthchecksPingURL, strconv.Itoa(exitCodeOrganizerNotFound), 3)
+				log.Printf("notifier run failed: %v", notFoundErr)
+				os.Exit(exitCodeOrganizerNotFound)
+			}
 			pingHealthchecks(reportCtx, httpClient, cfg.healthchecksPingURL, "fail", 3)
 
 			if panicVal != nil {
@@ -659,10 +1880,11 @@ func main() {
 		} else {
 			metricsClient.RecordExecutionSuccess(reportCtx, duration)
 			_ = metricsClient.Push(reportCtx)
-			pingHealthchecks(reportCtx, httpClient, cfg.healthchecksPingURL, "", 3)
+			_ = metricsClient.WriteTextfile()
+			pingRunSuccess(reportCtx, httpClient, cfg, summary)
 		}
 	}()
 
 	pingHealthchecks(ctx, httpClient, cfg.healthchecksPingURL, "start", 3)
-	runErr = runNotifier(ctx, httpClient, cfg, isLocal, metricsClient)
+	summary, runErr = runNotifier(ctx, httpClient, cfg, isLocal, metricsClient)
 }
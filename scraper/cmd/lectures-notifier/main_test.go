@@ -0,0 +1,545 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/metrics"
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/notifications"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestEventTags(t *testing.T) {
+	e := upcomingEvent("1", "Pints of Science", "Montreal", time.Date(2026, 3, 1, 19, 0, 0, 0, time.UTC))
+	e.Venue.Address.Region = "Q.C."
+
+	if got, want := eventTags(e), []string{"tada", "round_pushpin", "qc"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("eventTags(first announcement) = %v, want %v", got, want)
+	}
+	e.reminder = true
+	if got, want := eventTags(e), []string{"round_pushpin", "qc"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("eventTags(reminder) = %v, want %v", got, want)
+	}
+	if got := eventTags(event{ID: "2"}); !reflect.DeepEqual(got, []string{"tada"}) {
+		t.Fatalf("eventTags(no venue) = %v, want [tada]", got)
+	}
+}
+
+func TestMatchesPriceFilter(t *testing.T) {
+	free, paid := true, false
+	tests := []struct {
+		name   string
+		isFree *bool
+		filter string
+		want   bool
+	}{
+		{"free under all", &free, priceFilterAll, true},
+		{"paid under all", &paid, priceFilterAll, true},
+		{"unknown under all", nil, priceFilterAll, true},
+		{"free under free", &free, priceFilterFree, true},
+		{"paid under free", &paid, priceFilterFree, false},
+		{"unknown under free", nil, priceFilterFree, true},
+		{"free under paid", &free, priceFilterPaid, false},
+		{"paid under paid", &paid, priceFilterPaid, true},
+		{"unknown under paid", nil, priceFilterPaid, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesPriceFilter(event{ID: "1", IsFree: tt.isFree}, tt.filter); got != tt.want {
+				t.Fatalf("matchesPriceFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesKeywords(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		keywords []string
+		deny     []string
+		want     bool
+	}{
+		{"no lists", "Pints of Science", nil, nil, true},
+		{"allowed", "Pints of SCIENCE", []string{"science"}, nil, true},
+		{"not allowed", "Pub Quiz", []string{"science"}, nil, false},
+		{"denied", "Science Trivia", nil, []string{"trivia"}, false},
+		{"deny wins over allow", "Science Trivia", []string{"science"}, []string{"trivia"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesKeywords(tt.title, tt.keywords, tt.deny); got != tt.want {
+				t.Fatalf("matchesKeywords(%q) = %v, want %v", tt.title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapacityPhrase(t *testing.T) {
+	tests := []struct {
+		name      string
+		classes   string
+		threshold int
+		want      string
+	}{
+		{"below threshold", `[{"quantity_total": 50, "quantity_sold": 45}]`, 10, "only 5 left!"},
+		{"summed across classes", `[{"quantity_total": 20, "quantity_sold": 17}, {"quantity_total": 10, "quantity_sold": 6}]`, 10, "only 7 left!"},
+		{"at threshold", `[{"quantity_total": 50, "quantity_sold": 40}]`, 10, ""},
+		{"above threshold", `[{"quantity_total": 50, "quantity_sold": 10}]`, 10, ""},
+		{"sold out", `[{"quantity_total": 50, "quantity_sold": 50}]`, 10, ""},
+		{"disabled", `[{"quantity_total": 50, "quantity_sold": 45}]`, 0, ""},
+		{"no ticket classes", `[]`, 10, ""},
+		{"missing quantity", `[{"quantity_total": 50, "quantity_sold": 45}, {"quantity_total": 10}]`, 10, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e event
+			if err := json.Unmarshal([]byte(`{"id": "1", "ticket_classes": `+tt.classes+`}`), &e); err != nil {
+				t.Fatal(err)
+			}
+			if got := capacityPhrase(e, tt.threshold); got != tt.want {
+				t.Fatalf("capacityPhrase() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterEventsCatchup(t *testing.T) {
+	client, fake := newFakeRedis(t)
+	now := time.Now()
+	cfg := buildDedupeConfig()
+	cfg.catchup = true
+	cfg.byURL = true
+	cfg.reminderCooldown = time.Hour
+
+	notified := upcomingEvent("1", "Pints of Science", "Montreal", now.Add(48*time.Hour))
+	notified.URL = "https://www.eventbrite.ca/e/pints-1"
+	fresh := upcomingEvent("2", "Brain Night", "Montreal", now.Add(48*time.Hour))
+	fresh.URL = "https://www.eventbrite.ca/e/brain-2"
+	fake.set(dedupeKey("1"), legacyDedupeValue, time.Hour)
+
+	got, available, _ := filterEvents(context.Background(), []event{notified, fresh}, client, cfg, filterConfig{}, now, nil, nil)
+	if !reflect.DeepEqual(eventIDs(got), []string{"1", "2"}) || available != 2 {
+		t.Fatalf("filterEvents() = %v (%d available), want every event notified", eventIDs(got), available)
+	}
+	for _, e := range got {
+		for _, key := range append(eventDedupeKeys(e, cfg), announcedKey(e.ID)) {
+			if _, ok := fake.get(key); !ok {
+				t.Fatalf("catch-up did not write %s", key)
+			}
+		}
+	}
+	if want := []string{urlDedupeKey("https://www.eventbrite.ca/e/pints-1"), announcedKey("1")}; !reflect.DeepEqual(got[0].claimed, want) {
+		t.Fatalf("claimed = %v, want %v (the pre-existing dedupe key is not this run's)", got[0].claimed, want)
+	}
+
+	// Releasing after a failed send must keep the earlier run's key.
+	releaseDedupeKeys(context.Background(), client, got, nil)
+	if _, ok := fake.get(dedupeKey("1")); !ok {
+		t.Fatal("releaseDedupeKeys deleted a dedupe key written by an earlier run")
+	}
+	if keys := fake.keyNames(); !reflect.DeepEqual(keys, []string{dedupeKey("1")}) {
+		t.Fatalf("keys after release = %v, want only %s", keys, dedupeKey("1"))
+	}
+}
+
+func TestFilterEventsRestockReleasedAfterFailedSend(t *testing.T) {
+	client, fake := newFakeRedis(t)
+	ctx := context.Background()
+	now := time.Now()
+	cfg := buildDedupeConfig()
+	cfg.notifyOnRestock = true
+	cfg.jsonValue = true
+
+	e := upcomingEvent("1", "Pints of Science", "Montreal", now.Add(48*time.Hour))
+	fake.set(dedupeKey("1"), encodeDedupeRecord(dedupeRecord{NotifiedAt: now.Add(-time.Hour).Unix()}), time.Hour)
+
+	soldOut := e
+	soldOut.TicketAvailability = &ticketAvailability{HasAvailableTickets: new(bool)}
+	if got, _, _ := filterEvents(ctx, []event{soldOut}, client, cfg, filterConfig{}, now, nil, nil); len(got) != 0 {
+		t.Fatalf("filterEvents(sold out) = %v, want nothing to notify", eventIDs(got))
+	}
+
+	got, _, _ := filterEvents(ctx, []event{e}, client, cfg, filterConfig{}, now, nil, nil)
+	if !reflect.DeepEqual(eventIDs(got), []string{"1"}) {
+		t.Fatalf("filterEvents(restocked) = %v, want the restock notified", eventIDs(got))
+	}
+	if !reflect.DeepEqual(got[0].claimed, []string{dedupeKey("1")}) {
+		t.Fatalf("claimed = %v, want the restocked dedupe key", got[0].claimed)
+	}
+
+	// The send fails, so the claim is rolled back and the next run retries.
+	releaseDedupeKeys(ctx, client, got, nil)
+	if got, _, _ := filterEvents(ctx, []event{e}, client, cfg, filterConfig{}, now, nil, nil); !reflect.DeepEqual(eventIDs(got), []string{"1"}) {
+		t.Fatalf("filterEvents(after failed send) = %v, want the event notified again", eventIDs(got))
+	}
+}
+
+func TestGroupEventsByState(t *testing.T) {
+	now := time.Now()
+	inState := func(id, state string, start time.Time) event {
+		e := upcomingEvent(id, "Event "+id, "City", start)
+		e.Venue.Address.Region = state
+		return e
+	}
+	noVenue := upcomingEvent("5", "Event 5", "", now.Add(time.Hour))
+	noVenue.Venue = nil
+	events := []event{
+		inState("1", "QC", now.Add(3*time.Hour)),
+		inState("2", "ON", now.Add(time.Hour)),
+		inState("3", "QC", now.Add(time.Hour)),
+		inState("4", " ON ", now.Add(2*time.Hour)),
+		noVenue,
+	}
+
+	groups := groupEventsByState(events)
+	got := make(map[string][]string)
+	var states []string
+	for _, g := range groups {
+		states = append(states, g.state)
+		got[g.state] = eventIDs(g.events)
+	}
+	if want := []string{"", "ON", "QC"}; !reflect.DeepEqual(states, want) {
+		t.Fatalf("states = %q, want %q", states, want)
+	}
+	// Every event lands in exactly one digest, earliest first.
+	want := map[string][]string{"": {"5"}, "ON": {"2", "4"}, "QC": {"3", "1"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("groups = %v, want %v", got, want)
+	}
+}
+
+func TestFormatDigestMessage(t *testing.T) {
+	now := time.Now()
+	var events []event
+	for _, id := range []string{"1", "2", "3"} {
+		events = append(events, upcomingEvent(id, "Event "+id, "Montreal", now.Add(time.Hour)))
+	}
+	msgCfg := messageConfig{}
+	line := func(e event) string { return "\n- " + formatEventMessage(e, msgCfg) }
+
+	tests := []struct {
+		name     string
+		state    string
+		maxItems int
+		want     string
+	}{
+		{"all shown", "QC", 0, "3 new events in QC" + line(events[0]) + line(events[1]) + line(events[2])},
+		{"exactly max", "QC", 3, "3 new events in QC" + line(events[0]) + line(events[1]) + line(events[2])},
+		{"truncated", "QC", 2, "3 new events in QC" + line(events[0]) + line(events[1]) + "\n...and 1 more"},
+		{"no state", "", 1, "3 new events" + line(events[0]) + "\n...and 2 more"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := formatDigestMessage(tc.state, events, tc.maxItems, msgCfg); got != tc.want {
+				t.Fatalf("formatDigestMessage() =\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunNotifierSoftBudgetReleasesSkippedEvents(t *testing.T) {
+	client, fake := newFakeRedis(t)
+	now := time.Now()
+	events := []event{
+		upcomingEvent("1", "Pints of Science", "Montreal", now.Add(48*time.Hour)),
+		upcomingEvent("2", "Brain Night", "Montreal", now.Add(49*time.Hour)),
+		upcomingEvent("3", "Star Party", "Montreal", now.Add(50*time.Hour)),
+	}
+	httpClient := newEventBriteClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v3/organizers/") {
+			json.NewEncoder(w).Encode(eventBritePage(events, 1))
+			return
+		}
+		// The webhook outlasts the budget, so only the first event is sent.
+		time.Sleep(300 * time.Millisecond)
+	}))
+
+	cfg := appConfig{
+		orgIDs:        []string{"org"},
+		token:         "token",
+		softRunBudget: 200 * time.Millisecond,
+		dedupe:        buildDedupeConfig(),
+		notify:        notifications.Config{WebhookURL: "http://hooks.example.com/notify", WebhookTimeout: 5 * time.Second},
+		redisDial:     func(bool) *redis.Client { return client },
+	}
+	summary, err := runNotifier(context.Background(), httpClient, cfg, false, nil)
+	if err != nil {
+		t.Fatalf("runNotifier() error = %v", err)
+	}
+	if summary.notified != 1 || summary.budgetSkipped != 2 {
+		t.Fatalf("summary = %+v, want 1 notified and 2 budget-skipped", summary)
+	}
+	if _, ok := fake.get(dedupeKey("1")); !ok {
+		t.Fatal("the notified event lost its dedupe key")
+	}
+	for _, id := range []string{"2", "3"} {
+		if _, ok := fake.get(dedupeKey(id)); ok {
+			t.Fatalf("budget-skipped event %s kept its dedupe key", id)
+		}
+	}
+
+	var mu sync.Mutex
+	var pings []string
+	hc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		pings = append(pings, r.URL.Path)
+	}))
+	defer hc.Close()
+	cfg.healthchecksPingURL = hc.URL + "/check"
+	pingRunSuccess(context.Background(), hc.Client(), cfg, summary)
+	if want := []string{"/check/log", "/check"}; !reflect.DeepEqual(pings, want) {
+		t.Fatalf("healthchecks pings = %v, want %v", pings, want)
+	}
+}
+
+func TestFetchUnauthorizedIsAuthError(t *testing.T) {
+	httpClient := newEventBriteClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"INVALID_AUTH"}`, http.StatusUnauthorized)
+	}))
+
+	for _, orgIDs := range [][]string{{"org"}, {"org-a", "org-b"}} {
+		_, err := fetchAllOrganizers(context.Background(), httpClient, orgIDs, "token", fetchConfig{}, nil)
+		var authErr *AuthError
+		if !errors.As(err, &authErr) {
+			t.Fatalf("fetchAllOrganizers(%v) error = %v, want an *AuthError", orgIDs, err)
+		}
+		if authErr.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("AuthError.StatusCode = %d, want 401", authErr.StatusCode)
+		}
+	}
+}
+
+func TestDedupDisableSkipsRedis(t *testing.T) {
+	t.Setenv("DEDUP_DISABLE", "true")
+	client, fake := newFakeRedis(t)
+	now := time.Now()
+	notified := upcomingEvent("1", "Pints of Science", "Montreal", now.Add(48*time.Hour))
+	fake.set(dedupeKey("1"), legacyDedupeValue, time.Hour)
+	soldOut := upcomingEvent("2", "Brain Night", "Montreal", now.Add(48*time.Hour))
+	*soldOut.TicketAvailability.HasAvailableTickets = false
+	fresh := upcomingEvent("3", "Star Party", "Montreal", now.Add(48*time.Hour))
+
+	dialed := false
+	redisClient, dedupeCfg := initRedis(context.Background(), false, func(bool) *redis.Client {
+		dialed = true
+		return client
+	}, buildDedupeConfig(), nil)
+	if redisClient != nil || dialed {
+		t.Fatalf("initRedis() with DEDUP_DISABLE returned %v (dialed=%t), want no client", redisClient, dialed)
+	}
+
+	got, available, _ := filterEvents(context.Background(), []event{notified, soldOut, fresh}, redisClient, dedupeCfg, filterConfig{}, now, nil, nil)
+	if !reflect.DeepEqual(eventIDs(got), []string{"1", "3"}) || available != 2 {
+		t.Fatalf("filterEvents() = %v (%d available), want every available event", eventIDs(got), available)
+	}
+	if len(fake.commands) != 0 {
+		t.Fatalf("redis commands = %v, want none", fake.commands)
+	}
+}
+
+func TestFetchPageDelaySpacesSequentialPages(t *testing.T) {
+	const pageDelay = 100 * time.Millisecond
+	var mu sync.Mutex
+	requested := make(map[string]time.Time)
+	httpClient := newEventBriteClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		mu.Lock()
+		requested[page] = time.Now()
+		mu.Unlock()
+		json.NewEncoder(w).Encode(eventBritePage([]event{upcomingEvent(page, "Event "+page, "Montreal", time.Now().Add(time.Hour))}, 3))
+	}))
+
+	events, err := fetchAllLiveEvents(context.Background(), httpClient, "org", "token", fetchConfig{concurrency: 1, pageDelay: pageDelay}, nil)
+	if err != nil {
+		t.Fatalf("fetchAllLiveEvents() error = %v", err)
+	}
+	if !reflect.DeepEqual(eventIDs(events), []string{"1", "2", "3"}) {
+		t.Fatalf("events = %v, want one per page in order", eventIDs(events))
+	}
+	// Allow for scheduling jitter between the client's wait and the server
+	// seeing the request.
+	for _, pair := range [][2]string{{"1", "2"}, {"2", "3"}} {
+		if gap := requested[pair[1]].Sub(requested[pair[0]]); gap < pageDelay-10*time.Millisecond {
+			t.Fatalf("page %s requested %v after page %s, want at least %v", pair[1], gap, pair[0], pageDelay)
+		}
+	}
+}
+
+func TestDedupeTTLJitter(t *testing.T) {
+	const jitter = 30 * time.Minute
+	cfg := dedupeConfig{ttlCap: 14 * 24 * time.Hour, extraBuffer: time.Hour, minTTL: time.Hour, ttlJitter: jitter}
+	base := 49 * time.Hour
+
+	for _, tc := range []struct {
+		name string
+		pick func(n int64) int64
+		add  time.Duration
+	}{
+		{"no jitter drawn", func(int64) int64 { return 0 }, 0},
+		{"largest jitter", func(n int64) int64 { return n - 1 }, jitter},
+		{"midway", func(n int64) int64 { return n / 2 }, jitter / 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var bound int64
+			cfg.jitterInt63n = func(n int64) int64 {
+				bound = n
+				return tc.pick(n)
+			}
+			got := dedupeTTL(time.Now().Add(48*time.Hour), true, cfg)
+			if bound != int64(jitter)+1 {
+				t.Fatalf("jitter drawn from [0, %v), want [0, %v]", time.Duration(bound), jitter)
+			}
+			// time.Until runs a moment after the start was computed.
+			if want := base + tc.add; got > want || got < want-time.Second {
+				t.Fatalf("dedupeTTL() = %v, want %v", got, want)
+			}
+			if got < base-time.Second || got > base+jitter {
+				t.Fatalf("dedupeTTL() = %v outside [%v, %v]", got, base, base+jitter)
+			}
+		})
+	}
+
+	cfg.jitterInt63n = func(n int64) int64 { return n - 1 }
+	if got := dedupeTTL(time.Now().Add(cfg.ttlCap), true, cfg); got != cfg.ttlCap {
+		t.Fatalf("dedupeTTL(past the cap) = %v, want the %v cap despite jitter", got, cfg.ttlCap)
+	}
+}
+
+func TestFilterEventsSalesEnded(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	withSalesEnd := func(id, end string) event {
+		e := upcomingEvent(id, "Event "+id, "Montreal", now.Add(48*time.Hour))
+		if end != "" {
+			e.TicketAvailability.EndSalesDate = &struct {
+				UTC string `json:"utc"`
+			}{UTC: end}
+		}
+		return e
+	}
+	tests := []struct {
+		name string
+		e    event
+		skip bool
+		want bool
+	}{
+		{"sales ended", withSalesEnd("1", "2026-03-01T11:00:00Z"), true, false},
+		{"sales open", withSalesEnd("2", "2026-03-02T11:00:00Z"), true, true},
+		{"no sales end", withSalesEnd("3", ""), true, true},
+		{"unparseable sales end", withSalesEnd("4", "soon"), true, true},
+		{"sales ended, filter off", withSalesEnd("5", "2026-03-01T11:00:00Z"), false, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, _, _ := filterEvents(context.Background(), []event{tc.e}, nil, dedupeConfig{}, filterConfig{skipSalesEnded: tc.skip}, now, nil, nil)
+			if notified := len(got) == 1; notified != tc.want {
+				t.Fatalf("filterEvents() notified = %t, want %t", notified, tc.want)
+			}
+		})
+	}
+}
+
+func TestRedisKeyPrefix(t *testing.T) {
+	t.Setenv("REDIS_KEY_PREFIX", " staging: ")
+	defer func(prev string) { redisKeyPrefix = prev }(redisKeyPrefix)
+	redisKeyPrefix = loadRedisKeyPrefix()
+
+	if got, want := dedupeKey("1"), "staging:event:1:notified"; got != want {
+		t.Fatalf("dedupeKey() = %q, want %q", got, want)
+	}
+
+	// A sold-out event drops only this deployment's key.
+	client, fake := newFakeRedis(t)
+	fake.set("staging:event:1:notified", legacyDedupeValue, time.Hour)
+	fake.set("lot:event:1:notified", legacyDedupeValue, time.Hour)
+	now := time.Now()
+	soldOut := upcomingEvent("1", "Pints of Science", "Montreal", now.Add(48*time.Hour))
+	*soldOut.TicketAvailability.HasAvailableTickets = false
+	filterEvents(context.Background(), []event{soldOut}, client, buildDedupeConfig(), filterConfig{}, now, nil, nil)
+	if keys := fake.keyNames(); !reflect.DeepEqual(keys, []string{"lot:event:1:notified"}) {
+		t.Fatalf("keys after sold-out = %v, want only the default-prefix key kept", keys)
+	}
+}
+
+func TestFetchStopsAtMaxEvents(t *testing.T) {
+	const pageCount, perPage = 6, 2
+	var mu sync.Mutex
+	requested := make(map[string][]string)
+	httpClient := newEventBriteClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		org := strings.Split(strings.TrimPrefix(r.URL.Path, "/v3/organizers/"), "/")[0]
+		page := r.URL.Query().Get("page")
+		mu.Lock()
+		requested[org] = append(requested[org], page)
+		mu.Unlock()
+		var events []event
+		for i := range perPage {
+			id := org + "-" + page + "-" + string(rune('a'+i))
+			events = append(events, upcomingEvent(id, "Event "+id, "Montreal", time.Now().Add(time.Hour)))
+		}
+		json.NewEncoder(w).Encode(eventBritePage(events, pageCount))
+	}))
+
+	events, err := fetchAllOrganizers(context.Background(), httpClient, []string{"a", "b"}, "token", fetchConfig{concurrency: 1, maxEvents: 3}, nil)
+	if err != nil {
+		t.Fatalf("fetchAllOrganizers() error = %v", err)
+	}
+	if want := []string{"a-1-a", "a-1-b", "a-2-a"}; !reflect.DeepEqual(eventIDs(events), want) {
+		t.Fatalf("events = %v, want the first %d in page order", eventIDs(events), len(want))
+	}
+	// The page that reached the cap may race one more page already handed
+	// to the worker, but no further.
+	if pages := requested["a"]; len(pages) > 3 {
+		t.Fatalf("organizer a pages requested = %v, want fetching to stop at the cap", pages)
+	}
+	if pages, ok := requested["b"]; ok {
+		t.Fatalf("organizer b pages requested = %v, want none once the cap was reached", pages)
+	}
+}
+
+func TestDedupAnalyzeWritesNothing(t *testing.T) {
+	client, fake := newFakeRedis(t)
+	now := time.Now()
+	notified := upcomingEvent("1", "Pints of Science", "Montreal", now.Add(48*time.Hour))
+	fresh := upcomingEvent("2", "Brain Night", "Montreal", now.Add(48*time.Hour))
+	soldOut := upcomingEvent("3", "Star Party", "Montreal", now.Add(48*time.Hour))
+	*soldOut.TicketAvailability.HasAvailableTickets = false
+	fake.set(dedupeKey("1"), legacyDedupeValue, time.Hour)
+	fake.set(dedupeKey("3"), legacyDedupeValue, time.Hour)
+	httpClient := newEventBriteClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(eventBritePage([]event{notified, fresh, soldOut}, 1))
+	}))
+
+	dedupeCfg := buildDedupeConfig()
+	dedupeCfg.reminderCooldown = time.Hour
+	cfg := appConfig{
+		orgIDs:              []string{"org"},
+		token:               "token",
+		dedupeAnalyze:       true,
+		notifyCancellations: true,
+		runLock:             runLockConfig{enabled: true, ttl: time.Minute},
+		dedupe:              dedupeCfg,
+		redisDial:           func(bool) *redis.Client { return client },
+	}
+	m := metrics.NewMetrics("", "")
+	if _, err := runNotifier(context.Background(), httpClient, cfg, true, m); err != nil {
+		t.Fatalf("runNotifier() error = %v", err)
+	}
+	if w := fake.writes(); len(w) != 0 {
+		t.Fatalf("DEDUP_ANALYZE wrote to redis: %v", w)
+	}
+	if keys := fake.keyNames(); !reflect.DeepEqual(keys, []string{dedupeKey("1"), dedupeKey("3")}) {
+		t.Fatalf("keys = %v, want the existing keys untouched", keys)
+	}
+	if got := gaugeValue(t, m.LastRunItemsWouldNotify); got != 1 {
+		t.Fatalf("would-notify = %v, want 1 (only the fresh event)", got)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/metrics"
+)
+
+// exitCodeOrganizerNotFound is both the process exit code and the
+// healthchecks exit-status suffix used when an organizer ID does not exist.
+const exitCodeOrganizerNotFound = 3
+
+// OrganizerNotFoundError reports that EventBrite has no organizer with ID,
+// which otherwise shows up as a run that never finds any events.
+type OrganizerNotFoundError struct {
+	ID string
+}
+
+func (e *OrganizerNotFoundError) Error() string {
+	return fmt.Sprintf("eventbrite organizer %s not found, check EVENTBRITE_ORGANIZER_ID", e.ID)
+}
+
+const eventBriteOrganizerURL = "https://www.eventbriteapi.com/v3/organizers/%s/"
+
+// validateOrganizers looks up every organizer once before fetching events.
+// A 404 yields OrganizerNotFoundError and 401/403 an AuthError; any other
+// failure is returned as is.
+func validateOrganizers(ctx context.Context, client *http.Client, orgIDs []string, token string, m *metrics.Metrics) error {
+	for _, orgID := range orgIDs {
+		req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf(eventBriteOrganizerURL, orgID), nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("validate organizer %s: %w", orgID, err)
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			log.Printf("validated EventBrite organizer %s", orgID)
+		case resp.StatusCode == http.StatusNotFound:
+			return &OrganizerNotFoundError{ID: orgID}
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			m.RecordEventBriteAuthError()
+			return &AuthError{StatusCode: resp.StatusCode, Body: string(body)}
+		default:
+			return fmt.Errorf("validate organizer %s: eventbrite status %d: %s", orgID, resp.StatusCode, string(body))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestValidateOrganizersNotFoundIsNotAuthError(t *testing.T) {
+	httpClient := newEventBriteClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/organizers/known/":
+			w.Write([]byte(`{"id":"known"}`))
+		case "/v3/organizers/revoked/":
+			http.Error(w, `{"error":"INVALID_AUTH"}`, http.StatusUnauthorized)
+		default:
+			http.Error(w, `{"error":"NOT_FOUND"}`, http.StatusNotFound)
+		}
+	}))
+	ctx := context.Background()
+
+	if err := validateOrganizers(ctx, httpClient, []string{"known"}, "token", nil); err != nil {
+		t.Fatalf("validateOrganizers(known) error = %v", err)
+	}
+
+	err := validateOrganizers(ctx, httpClient, []string{"known", "missing"}, "token", nil)
+	var notFound *OrganizerNotFoundError
+	var authErr *AuthError
+	if !errors.As(err, &notFound) || notFound.ID != "missing" {
+		t.Fatalf("validateOrganizers(missing) error = %v, want OrganizerNotFoundError for missing", err)
+	}
+	if errors.As(err, &authErr) {
+		t.Fatalf("a 404 also matched AuthError: %v", err)
+	}
+
+	err = validateOrganizers(ctx, httpClient, []string{"revoked"}, "token", nil)
+	if !errors.As(err, &authErr) || errors.As(err, &notFound) {
+		t.Fatalf("validateOrganizers(revoked) error = %v, want only an AuthError", err)
+	}
+
+	// VALIDATE_ORGANIZER stops the run with the not-found error intact.
+	cfg := appConfig{orgIDs: []string{"missing"}, token: "token", validateOrganizer: true, redisDial: func(bool) *redis.Client { return nil }}
+	if _, err := runNotifier(ctx, httpClient, cfg, true, nil); !errors.As(err, &notFound) {
+		t.Fatalf("runNotifier() error = %v, want OrganizerNotFoundError", err)
+	}
+}
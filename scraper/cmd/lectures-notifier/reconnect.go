@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/metrics"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// redisReconnector hands out the run's Redis client, reconnecting on demand.
+// Concurrent callers share a single in-flight reconnection, and a failed
+// reconnection marks Redis broken for the rest of the run.
+type redisReconnector struct {
+	// connect makes one reconnection attempt, returning nil on failure.
+	connect func(ctx context.Context) *redis.Client
+	group   singleflight.Group
+
+	mu     sync.Mutex
+	client *redis.Client
+	broken bool
+}
+
+func newRedisReconnector(isLocal bool, client *redis.Client, broken bool, m *metrics.Metrics) *redisReconnector {
+	connect := func(ctx context.Context) *redis.Client {
+		return ensureRedisForNotification(ctx, isLocal, m)
+	}
+	return &redisReconnector{connect: connect, client: client, broken: broken}
+}
+
+// get returns the current client, attempting one shared reconnection when
+// there is none. It returns nil once Redis is known to be unavailable.
+func (r *redisReconnector) get(ctx context.Context) *redis.Client {
+	r.mu.Lock()
+	client, broken := r.client, r.broken
+	r.mu.Unlock()
+	if client != nil || broken {
+		return client
+	}
+
+	v, _, _ := r.group.Do("redis", func() (interface{}, error) {
+		client := r.connect(ctx)
+		r.mu.Lock()
+		r.client = client
+		r.broken = client == nil
+		r.mu.Unlock()
+		return client, nil
+	})
+	return v.(*redis.Client)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisReconnectorSharesOneAttempt(t *testing.T) {
+	restored, _ := newFakeRedis(t)
+	for _, tc := range []struct {
+		name string
+		want *redis.Client
+	}{
+		{"reconnect fails", nil},
+		{"reconnect succeeds", restored},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			const callers = 16
+			var attempts atomic.Int32
+			release := make(chan struct{})
+			r := newRedisReconnector(false, nil, false, nil)
+			r.connect = func(ctx context.Context) *redis.Client {
+				attempts.Add(1)
+				<-release
+				return tc.want
+			}
+
+			var started, done sync.WaitGroup
+			got := make([]*redis.Client, callers)
+			for i := range callers {
+				started.Add(1)
+				done.Add(1)
+				go func() {
+					defer done.Done()
+					started.Done()
+					got[i] = r.get(context.Background())
+				}()
+			}
+			started.Wait()
+			close(release)
+			done.Wait()
+
+			if n := attempts.Load(); n != 1 {
+				t.Fatalf("reconnect attempts = %d, want 1", n)
+			}
+			for i, c := range got {
+				if c != tc.want {
+					t.Fatalf("caller %d got %p, want %p", i, c, tc.want)
+				}
+			}
+			if c := r.get(context.Background()); c != tc.want || attempts.Load() != 1 {
+				t.Fatalf("later get() = %p after %d attempts, want %p without another attempt", c, attempts.Load(), tc.want)
+			}
+		})
+	}
+}
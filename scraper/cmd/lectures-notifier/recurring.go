@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	collapseKeyName      = "name"
+	collapseKeyNameVenue = "name+venue"
+)
+
+func normalizeKeyPart(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+func recurringKey(e event, keyMode string) string {
+	key := normalizeKeyPart(e.Name.Text)
+	if keyMode == collapseKeyNameVenue {
+		venue := ""
+		if e.Venue != nil {
+			a := e.Venue.Address
+			venue = normalizeKeyPart(a.Address1 + " " + a.City + " " + a.Region)
+		}
+		key += "|" + venue
+	}
+	return key
+}
+
+// collapseRecurring keeps only the soonest upcoming occurrence of events that
+// share a recurring key. Only events filterEvents could notify are candidates:
+// available, in the future, with a start time and passing the configured
+// filters. Everything else passes through, so sold-out handling and events
+// with unknown dates are unaffected and a filtered-out occurrence never hides
+// the rest of its series. Input order is preserved.
+func collapseRecurring(events []event, now time.Time, cfg filterConfig) ([]event, int) {
+	candidate := func(e event) bool {
+		start, ok := parseEventStart(e)
+		return ok && !start.Before(now) && !e.test && isTicketsAvailable(e) && filteredBy(e, cfg, now) == ""
+	}
+	soonest := make(map[string]int)
+	for i, e := range events {
+		if !candidate(e) {
+			continue
+		}
+		key := recurringKey(e, cfg.collapseKey)
+		if j, seen := soonest[key]; seen {
+			start, _ := parseEventStart(e)
+			if prev, _ := parseEventStart(events[j]); !start.Before(prev) {
+				continue
+			}
+		}
+		soonest[key] = i
+	}
+
+	kept := make([]event, 0, len(events))
+	collapsed := 0
+	for i, e := range events {
+		if candidate(e) && soonest[recurringKey(e, cfg.collapseKey)] != i {
+			collapsed++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept, collapsed
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// upcomingEvent builds an available event starting at start in city.
+func upcomingEvent(id, name, city string, start time.Time) event {
+	available := true
+	e := event{ID: id, Venue: &eventVenue{}, TicketAvailability: &ticketAvailability{HasAvailableTickets: &available}}
+	e.Name.Text = name
+	e.Start.Local = start.Format("2006-01-02T15:04:05")
+	e.Venue.Address.City = city
+	return e
+}
+
+func eventIDs(events []event) []string {
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestCollapseRecurring(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	salesEnded := upcomingEvent("week1", "Pints of Science", "Montreal", now.Add(day))
+	salesEnded.TicketAvailability.EndSalesDate = &struct {
+		UTC string `json:"utc"`
+	}{UTC: now.Add(-time.Hour).Format("2006-01-02T15:04:05Z")}
+	soldOut := upcomingEvent("week0", "Pints of Science", "Montreal", now.Add(day/2))
+	*soldOut.TicketAvailability.HasAvailableTickets = false
+
+	tests := []struct {
+		name          string
+		events        []event
+		cfg           filterConfig
+		wantIDs       []string
+		wantCollapsed int
+	}{
+		{
+			name: "keeps soonest occurrence of a series",
+			events: []event{
+				upcomingEvent("week3", "Pints of Science", "Montreal", now.Add(21*day)),
+				upcomingEvent("week1", "Pints of Science", "Montreal", now.Add(7*day)),
+				upcomingEvent("week2", " pints  of SCIENCE ", "Montreal", now.Add(14*day)),
+			},
+			cfg:           filterConfig{collapseKey: collapseKeyNameVenue},
+			wantIDs:       []string{"week1"},
+			wantCollapsed: 2,
+		},
+		{
+			name: "same name at different venues stays distinct",
+			events: []event{
+				upcomingEvent("mtl", "Pints of Science", "Montreal", now.Add(day)),
+				upcomingEvent("tor", "Pints of Science", "Toronto", now.Add(2*day)),
+			},
+			cfg:     filterConfig{collapseKey: collapseKeyNameVenue},
+			wantIDs: []string{"mtl", "tor"},
+		},
+		{
+			name: "name key ignores venue",
+			events: []event{
+				upcomingEvent("mtl", "Pints of Science", "Montreal", now.Add(day)),
+				upcomingEvent("tor", "Pints of Science", "Toronto", now.Add(2*day)),
+			},
+			cfg:           filterConfig{collapseKey: collapseKeyName},
+			wantIDs:       []string{"mtl"},
+			wantCollapsed: 1,
+		},
+		{
+			name: "past, sold-out and undated events pass through",
+			events: []event{
+				upcomingEvent("past", "Pints of Science", "Montreal", now.Add(-day)),
+				soldOut,
+				{ID: "undated"},
+				upcomingEvent("week1", "Pints of Science", "Montreal", now.Add(7*day)),
+			},
+			cfg:     filterConfig{collapseKey: collapseKeyNameVenue},
+			wantIDs: []string{"past", "week0", "undated", "week1"},
+		},
+		{
+			name: "filtered soonest occurrence does not hide the series",
+			events: []event{
+				salesEnded,
+				upcomingEvent("week2", "Pints of Science", "Montreal", now.Add(8*day)),
+				upcomingEvent("week3", "Pints of Science", "Montreal", now.Add(15*day)),
+			},
+			cfg:           filterConfig{collapseKey: collapseKeyNameVenue, skipSalesEnded: true},
+			wantIDs:       []string{"week1", "week2"},
+			wantCollapsed: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, collapsed := collapseRecurring(tt.events, now, tt.cfg)
+			if ids := eventIDs(got); !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Fatalf("collapseRecurring() kept %v, want %v", ids, tt.wantIDs)
+			}
+			if collapsed != tt.wantCollapsed {
+				t.Fatalf("collapseRecurring() collapsed %d, want %d", collapsed, tt.wantCollapsed)
+			}
+		})
+	}
+}
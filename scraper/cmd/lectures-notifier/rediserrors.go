@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisErrorClass separates Redis errors that retrying or proceeding can ride
+// out from those that point at a misconfiguration.
+type redisErrorClass int
+
+const (
+	redisErrorTransient redisErrorClass = iota
+	// redisErrorWrongType means a key holds another type, usually because
+	// another service writes under the same REDIS_KEY_PREFIX.
+	redisErrorWrongType
+	// redisErrorAuth covers NOAUTH, WRONGPASS and NOPERM replies.
+	redisErrorAuth
+)
+
+func (c redisErrorClass) String() string {
+	switch c {
+	case redisErrorWrongType:
+		return "wrongtype"
+	case redisErrorAuth:
+		return "auth"
+	default:
+		return "transient"
+	}
+}
+
+// misconfiguration reports whether the class points at setup rather than a
+// passing failure, which is what REDIS_FAIL_ON_MISCONFIG stops the run for.
+func (c redisErrorClass) misconfiguration() bool {
+	return c != redisErrorTransient
+}
+
+// errRedisWrongType is returned by the key helpers below when a key under
+// the scraper's prefix exists with a non-string type. SETNX and DEL never
+// reply WRONGTYPE themselves, so a collision is only visible through TYPE.
+var errRedisWrongType = errors.New("WRONGTYPE key holds a non-string value")
+
+func classifyRedisError(err error) redisErrorClass {
+	switch {
+	case err == nil:
+		return redisErrorTransient
+	case errors.Is(err, errRedisWrongType), redis.HasErrorPrefix(err, "WRONGTYPE"):
+		return redisErrorWrongType
+	case redis.IsAuthError(err), redis.IsPermissionError(err):
+		return redisErrorAuth
+	default:
+		return redisErrorTransient
+	}
+}
+
+// recordRedisError counts a failed Redis operation and returns its class.
+// Misconfiguration errors are also logged prominently and counted
+// separately; the caller's own fallback (usually proceeding to notify) still
+// applies unless it checks the class.
+func recordRedisError(err error, m *metrics.Metrics) redisErrorClass {
+	m.RecordRedisOperationError()
+	class := classifyRedisError(err)
+	if !class.misconfiguration() {
+		return class
+	}
+	m.RecordRedisMisconfigError()
+	hint := "check REDIS_KEY_PREFIX for a collision with another service"
+	if class == redisErrorAuth {
+		hint = "check REDIS_USERNAME, REDIS_PASSWORD and the user's ACL"
+	}
+	slog.Error("REDIS MISCONFIGURATION", "class", class.String(), "error", strings.TrimSpace(err.Error()), "hint", hint)
+	return class
+}
+
+// checkStringKey returns errRedisWrongType when key exists with a type other
+// than string. A failed TYPE lookup is not treated as a collision.
+func checkStringKey(ctx context.Context, redisClient *redis.Client, key string) error {
+	keyType, err := redisClient.Type(ctx, key).Result()
+	if err != nil || keyType == "string" || keyType == "none" {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is a %s", errRedisWrongType, key, keyType)
+}
+
+// claimKey is SETNX that also reports prefix collisions: when the key
+// already exists, its type is checked so a key owned by another writer
+// surfaces as errRedisWrongType instead of a silent dedupe skip.
+func claimKey(ctx context.Context, redisClient *redis.Client, key string, value any, ttl time.Duration) (bool, error) {
+	set, err := redisClient.SetNX(ctx, key, value, ttl).Result()
+	if err != nil || set {
+		return set, err
+	}
+	return false, checkStringKey(ctx, redisClient, key)
+}
+
+// refreshKey overwrites key with value and ttl and reports whether the key
+// did not exist before, so callers only release keys they introduced. SET
+// with GET rejects a key of another type, which classifies as wrong type.
+func refreshKey(ctx context.Context, redisClient *redis.Client, key string, value any, ttl time.Duration) (bool, error) {
+	err := redisClient.SetArgs(ctx, key, value, redis.SetArgs{TTL: ttl, Get: true}).Err()
+	if errors.Is(err, redis.Nil) {
+		return true, nil
+	}
+	return false, err
+}
+
+// deleteKey deletes one of the scraper's string keys, refusing to touch a
+// key of another type that merely shares the prefix.
+func deleteKey(ctx context.Context, redisClient *redis.Client, key string) (int64, error) {
+	if err := checkStringKey(ctx, redisClient, key); err != nil {
+		return 0, err
+	}
+	return redisClient.Del(ctx, key).Result()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// fakeRedisError stands in for a server error reply, which go-redis
+// recognizes through its RedisError marker method.
+type fakeRedisError string
+
+func (e fakeRedisError) Error() string { return string(e) }
+
+func (fakeRedisError) RedisError() {}
+
+func TestClassifyRedisError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want redisErrorClass
+	}{
+		{"nil", nil, redisErrorTransient},
+		{"wrongtype reply", fakeRedisError("WRONGTYPE Operation against a key holding the wrong kind of value"), redisErrorWrongType},
+		{"wrongtype from type check", fmt.Errorf("%w: lot:event:1:notified is a hash", errRedisWrongType), redisErrorWrongType},
+		{"noauth", fakeRedisError("NOAUTH Authentication required."), redisErrorAuth},
+		{"wrongpass", fakeRedisError("WRONGPASS invalid username-password pair or user is disabled."), redisErrorAuth},
+		{"noperm", fakeRedisError("NOPERM User scraper has no permissions to run the 'set' command"), redisErrorAuth},
+		{"loading", fakeRedisError("LOADING Redis is loading the dataset in memory"), redisErrorTransient},
+		{"network", errors.New("dial tcp 127.0.0.1:6379: connect: connection refused"), redisErrorTransient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyRedisError(tt.err)
+			if got != tt.want {
+				t.Fatalf("classifyRedisError(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+			if got.misconfiguration() != (tt.want != redisErrorTransient) {
+				t.Fatalf("%s.misconfiguration() = %v", got, got.misconfiguration())
+			}
+		})
+	}
+}
+
+func TestRecordRedisErrorReturnsClass(t *testing.T) {
+	err := fakeRedisError("NOPERM User scraper has no permissions to run the 'get' command")
+	if got := recordRedisError(err, nil); got != redisErrorAuth {
+		t.Fatalf("recordRedisError() = %s, want %s", got, redisErrorAuth)
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeRedis answers go-redis commands from memory through a process hook, so
+// tests exercise the real client calls without a server. It covers the
+// commands and scripts this package uses; TTLs are stored but never expire.
+type fakeRedis struct {
+	mu       sync.Mutex
+	keys     map[string]fakeKey
+	commands []string
+	// fail, when set, is returned by every command.
+	fail error
+}
+
+type fakeKey struct {
+	typ   string
+	value string
+	ttl   time.Duration
+}
+
+// fakeWriteCommands are the commands that change the keyspace.
+var fakeWriteCommands = map[string]bool{"set": true, "setnx": true, "del": true, "incr": true, "evalsha": true, "eval": true}
+
+func newFakeRedis(t *testing.T) (*redis.Client, *fakeRedis) {
+	t.Helper()
+	f := &fakeRedis{keys: make(map[string]fakeKey)}
+	client := redis.NewClient(&redis.Options{Addr: "fake-redis:6379", MaxRetries: -1})
+	client.AddHook(f)
+	t.Cleanup(func() { client.Close() })
+	return client, f
+}
+
+// set stores a string key as if an earlier run had written it.
+func (f *fakeRedis) set(key, value string, ttl time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys[key] = fakeKey{typ: "string", value: value, ttl: ttl}
+}
+
+// setType stores a key of another type, e.g. a hash owned by another service.
+func (f *fakeRedis) setType(key, typ string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys[key] = fakeKey{typ: typ}
+}
+
+func (f *fakeRedis) get(key string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k, ok := f.keys[key]
+	return k.value, ok
+}
+
+func (f *fakeRedis) ttl(key string) time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.keys[key].ttl
+}
+
+// keyNames returns the stored keys in sorted order.
+func (f *fakeRedis) keyNames() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := make([]string, 0, len(f.keys))
+	for k := range f.keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writes returns the keyspace-changing commands seen so far.
+func (f *fakeRedis) writes() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var w []string
+	for _, c := range f.commands {
+		if fakeWriteCommands[strings.Fields(c)[0]] {
+			w = append(w, c)
+		}
+	}
+	return w
+}
+
+func (f *fakeRedis) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, errors.New("fake redis does not dial")
+	}
+}
+
+func (f *fakeRedis) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		f.process(cmd)
+		return cmd.Err()
+	}
+}
+
+func (f *fakeRedis) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			f.process(cmd)
+		}
+		return nil
+	}
+}
+
+func (f *fakeRedis) process(cmd redis.Cmder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	args := make([]string, len(cmd.Args()))
+	for i, a := range cmd.Args() {
+		args[i] = fmt.Sprint(a)
+	}
+	name := strings.ToLower(args[0])
+	f.commands = append(f.commands, strings.Join(append([]string{name}, args[1:]...), " "))
+	if f.fail != nil {
+		cmd.SetErr(f.fail)
+		return
+	}
+
+	switch name {
+	case "ping":
+		cmd.(*redis.StatusCmd).SetVal("PONG")
+	case "get":
+		k, ok := f.keys[args[1]]
+		switch {
+		case !ok:
+			cmd.SetErr(redis.Nil)
+		case k.typ != "string":
+			cmd.SetErr(fakeRedisError("WRONGTYPE Operation against a key holding the wrong kind of value"))
+		default:
+			cmd.(*redis.StringCmd).SetVal(k.value)
+		}
+	case "set", "setnx":
+		f.processSet(cmd, name, args)
+	case "del":
+		var n int64
+		for _, key := range args[1:] {
+			if _, ok := f.keys[key]; ok {
+				delete(f.keys, key)
+				n++
+			}
+		}
+		cmd.(*redis.IntCmd).SetVal(n)
+	case "exists":
+		var n int64
+		for _, key := range args[1:] {
+			if _, ok := f.keys[key]; ok {
+				n++
+			}
+		}
+		cmd.(*redis.IntCmd).SetVal(n)
+	case "type":
+		typ := "none"
+		if k, ok := f.keys[args[1]]; ok {
+			typ = k.typ
+		}
+		cmd.(*redis.StatusCmd).SetVal(typ)
+	case "incr":
+		k := f.keys[args[1]]
+		n, _ := strconv.ParseInt(k.value, 10, 64)
+		n++
+		f.keys[args[1]] = fakeKey{typ: "string", value: strconv.FormatInt(n, 10), ttl: k.ttl}
+		cmd.(*redis.IntCmd).SetVal(n)
+	case "ttl":
+		k, ok := f.keys[args[1]]
+		switch {
+		case !ok:
+			cmd.(*redis.DurationCmd).SetVal(-2)
+		case k.ttl == 0:
+			cmd.(*redis.DurationCmd).SetVal(-1)
+		default:
+			cmd.(*redis.DurationCmd).SetVal(k.ttl)
+		}
+	case "scan":
+		match := "*"
+		for i := 2; i+1 < len(args); i++ {
+			if strings.EqualFold(args[i], "match") {
+				match = args[i+1]
+			}
+		}
+		var keys []string
+		for k := range f.keys {
+			if ok, _ := path.Match(match, k); ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		cmd.(*redis.ScanCmd).SetVal(keys, 0)
+	case "evalsha", "eval":
+		f.processScript(cmd, name, args)
+	default:
+		cmd.SetErr(fmt.Errorf("fake redis: unsupported command %q", name))
+	}
+}
+
+// processSet handles SET with EX/PX/KEEPTTL/NX/XX/GET and SETNX, replying in
+// the shape of whichever go-redis command issued it.
+func (f *fakeRedis) processSet(cmd redis.Cmder, name string, args []string) {
+	key, value := args[1], args[2]
+	var ttl time.Duration
+	keepTTL, nx, xx, get := false, name == "setnx", false, false
+	for i := 3; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "ex":
+			secs, _ := strconv.Atoi(args[i+1])
+			ttl = time.Duration(secs) * time.Second
+			i++
+		case "px":
+			ms, _ := strconv.Atoi(args[i+1])
+			ttl = time.Duration(ms) * time.Millisecond
+			i++
+		case "keepttl":
+			keepTTL = true
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "get":
+			get = true
+		}
+	}
+
+	old, exists := f.keys[key]
+	if get && exists && old.typ != "string" {
+		cmd.SetErr(fakeRedisError("WRONGTYPE Operation against a key holding the wrong kind of value"))
+		return
+	}
+	written := !(nx && exists) && !(xx && !exists)
+	if written {
+		if keepTTL {
+			ttl = old.ttl
+		}
+		f.keys[key] = fakeKey{typ: "string", value: value, ttl: ttl}
+	}
+
+	switch c := cmd.(type) {
+	case *redis.BoolCmd:
+		c.SetVal(written)
+	case *redis.StatusCmd:
+		switch {
+		case get && exists:
+			c.SetVal(old.value)
+		case get || !written:
+			c.SetErr(redis.Nil)
+		default:
+			c.SetVal("OK")
+		}
+	}
+}
+
+// processScript runs the Go equivalent of the package's Lua scripts, found
+// by their SHA1 like EVALSHA does.
+func (f *fakeRedis) processScript(cmd redis.Cmder, name string, args []string) {
+	sha := args[1]
+	if name == "eval" {
+		sha = redis.NewScript(args[1]).Hash()
+	}
+	numKeys, _ := strconv.Atoi(args[2])
+	keys, argv := args[3:3+numKeys], args[3+numKeys:]
+
+	c := cmd.(*redis.Cmd)
+	switch sha {
+	case swapDedupeValueScript.Hash():
+		k, ok := f.keys[keys[0]]
+		if !ok || k.value != argv[0] {
+			c.SetVal(int64(0))
+			return
+		}
+		k.value = argv[1]
+		f.keys[keys[0]] = k
+		c.SetVal(int64(1))
+	case releaseRunLockScript.Hash():
+		if k, ok := f.keys[keys[0]]; ok && k.value == argv[0] {
+			delete(f.keys, keys[0])
+			c.SetVal(int64(1))
+			return
+		}
+		c.SetVal(int64(0))
+	default:
+		c.SetErr(fakeRedisError("NOSCRIPT No matching script."))
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// announcedKey outlives the notified key when reminders are enabled, so a
+// lapsed notified key can be told apart from an event never seen before.
+func announcedKey(eventID string) string {
+	return eventKeyPrefix(eventID) + "announced"
+}
+
+// remindedKey prevents more than one reminder per event.
+func remindedKey(eventID string) string {
+	return eventKeyPrefix(eventID) + "reminded"
+}
+
+func remindersEnabled(cfg dedupeConfig) bool {
+	return cfg.reminderCooldown > 0
+}
+
+// fullDedupeTTL is the event's dedupe TTL without the reminder cooldown cap.
+func fullDedupeTTL(start time.Time, hasStart bool, cfg dedupeConfig) time.Duration {
+	cfg.reminderCooldown = 0
+	return dedupeTTL(start, hasStart, cfg)
+}
+
+// classifyReminder runs after an event claimed its notified key while
+// reminders are enabled. It returns whether to notify and whether that
+// notification is a reminder: a first notification records the announced
+// key; an already-announced event is only re-sent, once, as a reminder when
+// it starts within the reminder window. A returned error has already been
+// logged and counted.
+func classifyReminder(ctx context.Context, redisClient *redis.Client, e event, startTime time.Time, hasStart bool, cfg dedupeConfig, now time.Time, m *metrics.Metrics) (notify, reminder bool, err error) {
+	ttl := fullDedupeTTL(startTime, hasStart, cfg)
+	set, err := claimKey(ctx, redisClient, announcedKey(e.ID), "1", ttl)
+	if err != nil {
+		if recordRedisError(err, m) == redisErrorWrongType {
+			slog.Error("redis key collides with another writer, skipping", "stage", "filter", "key", announcedKey(e.ID), "event_id", e.ID)
+			return false, false, err
+		}
+		slog.Error("redis setnx failed, treating as first notification", "stage", "filter", "key", announcedKey(e.ID), "event_id", e.ID, "error", err)
+		return true, false, err
+	}
+	if set {
+		return true, false, nil
+	}
+
+	if !hasStart || startTime.Sub(now) > cfg.reminderWindow {
+		slog.Info("reminder not due", "stage", "filter", "event_id", e.ID, "event_name", e.Name.Text, "start", startTime, "window", cfg.reminderWindow)
+		return false, false, nil
+	}
+	set, err = claimKey(ctx, redisClient, remindedKey(e.ID), "1", ttl)
+	if err != nil {
+		slog.Error("redis setnx failed, skipping reminder", "stage", "filter", "key", remindedKey(e.ID), "event_id", e.ID, "error", err)
+		recordRedisError(err, m)
+		return false, false, err
+	}
+	if !set {
+		slog.Info("reminder already sent", "stage", "filter", "event_id", e.ID, "event_name", e.Name.Text)
+		return false, false, nil
+	}
+	slog.Info("sending reminder", "stage", "filter", "event_id", e.ID, "event_name", e.Name.Text, "start", startTime)
+	return true, true, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/notifications"
+)
+
+// runReport accumulates what a run did so it can be written as a JSON
+// artifact at RUN_REPORT_PATH. A nil *runReport records nothing.
+type runReport struct {
+	mu   sync.Mutex
+	path string
+
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	Error      string        `json:"error,omitempty"`
+	Counts     reportCounts  `json:"counts"`
+	Events     []reportEvent `json:"events"`
+}
+
+type reportCounts struct {
+	Processed     int `json:"processed"`
+	Available     int `json:"available"`
+	Notified      int `json:"notified"`
+	Deduped       int `json:"deduped"`
+	SoldOut       int `json:"sold_out"`
+	BudgetSkipped int `json:"budget_skipped"`
+}
+
+type reportEvent struct {
+	ID           string              `json:"id"`
+	Name         string              `json:"name"`
+	Destinations []reportDestination `json:"destinations"`
+}
+
+type reportDestination struct {
+	Notifier string `json:"notifier"`
+	OK       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+}
+
+// deliveredNames returns the sorted names of notifiers that accepted a notification.
+func deliveredNames(results []notifications.Result) []string {
+	var names []string
+	for _, r := range results {
+		if r.Err == nil {
+			names = append(names, r.Notifier)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newRunReport(path string, startedAt time.Time) *runReport {
+	if path == "" {
+		return nil
+	}
+	return &runReport{path: path, StartedAt: startedAt}
+}
+
+func (r *runReport) setCounts(processed, available int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Counts.Processed = processed
+	r.Counts.Available = available
+}
+
+func (r *runReport) recordSoldOut() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Counts.SoldOut++
+}
+
+func (r *runReport) recordDeduplicated() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Counts.Deduped++
+}
+
+// recordDelivery adds an event with the per-notifier outcome of publishing
+// it. The event counts as notified when at least one notifier accepted it.
+func (r *runReport) recordDelivery(e event, results []notifications.Result) {
+	if r == nil {
+		return
+	}
+	re := reportEvent{ID: e.ID, Name: e.Name.Text, Destinations: make([]reportDestination, 0, len(results))}
+	notified := false
+	for _, res := range results {
+		d := reportDestination{Notifier: res.Notifier, OK: res.Err == nil}
+		if res.Err != nil {
+			d.Error = res.Err.Error()
+		} else {
+			notified = true
+		}
+		re.Destinations = append(re.Destinations, d)
+	}
+	sort.Slice(re.Destinations, func(i, j int) bool { return re.Destinations[i].Notifier < re.Destinations[j].Notifier })
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Events = append(r.Events, re)
+	if notified {
+		r.Counts.Notified++
+	}
+}
+
+// localNotifier names the stdout "destination" of local runs in the report.
+const localNotifier = "local"
+
+// localResults is the delivery outcome of an event printed in local mode.
+func localResults() []notifications.Result {
+	return []notifications.Result{{Notifier: localNotifier}}
+}
+
+// write stores the report at its path, replacing any previous report.
+func (r *runReport) write(summary runSummary, runErr error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.FinishedAt = time.Now()
+	r.Counts.BudgetSkipped = summary.budgetSkipped
+	if runErr != nil {
+		r.Error = runErr.Error()
+	}
+	if r.Events == nil {
+		r.Events = []reportEvent{}
+	}
+
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		log.Printf("failed to encode run report: %v", err)
+		return
+	}
+	if err := os.WriteFile(r.path, append(b, '\n'), 0o644); err != nil {
+		log.Printf("failed to write run report %s: %v", r.path, err)
+		return
+	}
+	log.Printf("wrote run report to %s (%d events)", r.path, len(r.Events))
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// markDedupeSoldOut flags an already-notified event as sold out instead of
+// deleting its key, so DEDUP_NOTIFY_ON_RESTOCK can tell a restock from an
+// event that merely stayed available. Events never notified are left alone.
+// A returned error has already been logged and counted.
+func markDedupeSoldOut(ctx context.Context, redisClient *redis.Client, e event, now time.Time, m *metrics.Metrics) error {
+	redisKey := dedupeKey(e.ID)
+	current, err := redisClient.Get(ctx, redisKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		slog.Error("redis get failed", "stage", "filter", "key", redisKey, "event_id", e.ID, "error", err)
+		recordRedisError(err, m)
+		return err
+	}
+	rec, err := parseDedupeRecord(current)
+	if err != nil {
+		slog.Warn("redis dedupe value unreadable, overwriting", "stage", "filter", "key", redisKey, "event_id", e.ID, "error", err)
+	}
+	if rec.SoldOut {
+		return nil
+	}
+	rec.SoldOut = true
+	rec.SoldOutAt = now.Unix()
+	swapped, err := compareAndSwap(ctx, redisClient, redisKey, current, encodeDedupeRecord(rec))
+	if err != nil {
+		slog.Error("redis set failed", "stage", "filter", "key", redisKey, "event_id", e.ID, "error", err)
+		recordRedisError(err, m)
+		return err
+	}
+	if !swapped {
+		slog.Info("redis key changed while marking the event sold out, leaving it", "stage", "filter", "key", redisKey, "event_id", e.ID)
+		return nil
+	}
+	slog.Info("redis marked key sold out", "stage", "filter", "key", redisKey, "event_id", e.ID, "event_name", e.Name.Text)
+	return nil
+}
+
+// claimRestock reports whether an event whose dedupe key already exists was
+// last seen sold out, and if so clears the flag so the restock notifies once.
+// The flag is cleared with a compare-and-swap, so of two overlapping runs
+// only one claims the restock. A returned error has already been logged and
+// counted.
+func claimRestock(ctx context.Context, redisClient *redis.Client, e event, now time.Time, m *metrics.Metrics) (bool, error) {
+	redisKey := dedupeKey(e.ID)
+	current, err := redisClient.Get(ctx, redisKey).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		slog.Error("redis get failed", "stage", "filter", "key", redisKey, "event_id", e.ID, "error", err)
+		recordRedisError(err, m)
+		return false, err
+	}
+	rec, err := parseDedupeRecord(current)
+	if err != nil || !rec.SoldOut {
+		return false, nil
+	}
+	swapped, err := compareAndSwap(ctx, redisClient, redisKey, current, encodeDedupeRecord(dedupeRecord{NotifiedAt: now.Unix()}))
+	if err != nil {
+		slog.Error("redis set failed, skipping restock", "stage", "filter", "key", redisKey, "event_id", e.ID, "error", err)
+		recordRedisError(err, m)
+		return false, err
+	}
+	if !swapped {
+		slog.Info("restock already claimed by another run", "stage", "filter", "event_id", e.ID)
+		return false, nil
+	}
+	slog.Info("restock: event available again after selling out", "stage", "filter", "event_id", e.ID, "event_name", e.Name.Text, "sold_out_at", time.Unix(rec.SoldOutAt, 0).UTC())
+	m.RecordEventRestocked()
+	return true, nil
+}
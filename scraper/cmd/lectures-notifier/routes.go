@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/notifications"
+)
+
+// notifierRoute sends events matching every set attribute to the named
+// notifiers only. Rules are tried in order and the first match wins; an
+// event no rule matches goes to every notifier.
+//
+//	[{"online": true, "notifiers": ["discord"]}, {"online": false, "notifiers": ["ntfy"]}]
+type notifierRoute struct {
+	Online    *bool    `json:"online,omitempty"`
+	Free      *bool    `json:"free,omitempty"`
+	States    []string `json:"states,omitempty"`
+	Notifiers []string `json:"notifiers"`
+}
+
+// parseNotifierRoutes decodes NOTIFIER_ROUTES. An empty value means no rules.
+func parseNotifierRoutes(raw string) ([]notifierRoute, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	var routes []notifierRoute
+	if err := json.Unmarshal([]byte(raw), &routes); err != nil {
+		return nil, fmt.Errorf("parse NOTIFIER_ROUTES: %w", err)
+	}
+	for i, r := range routes {
+		if len(r.Notifiers) == 0 {
+			return nil, fmt.Errorf("NOTIFIER_ROUTES rule %d has no notifiers", i)
+		}
+	}
+	return routes, nil
+}
+
+func buildNotifierRoutes() []notifierRoute {
+	routes, err := parseNotifierRoutes(os.Getenv("NOTIFIER_ROUTES"))
+	if err != nil {
+		log.Fatalf("invalid notifier routes: %v", err)
+	}
+	return routes
+}
+
+func (r notifierRoute) matches(e event) bool {
+	if r.Online != nil && *r.Online != e.OnlineEvent {
+		return false
+	}
+	if r.Free != nil && (e.IsFree == nil || *r.Free != *e.IsFree) {
+		return false
+	}
+	if len(r.States) > 0 {
+		state := eventState(e)
+		found := false
+		for _, s := range r.States {
+			if strings.EqualFold(strings.TrimSpace(s), state) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// routeNotifiers returns the notifiers that should receive e, keeping their
+// order. Without rules, or when no rule matches, every notifier is returned.
+// The synthetic test event only goes to the test destination.
+func routeNotifiers(e event, notifiers []notifications.Notifier, rules []notifierRoute) []notifications.Notifier {
+	if e.test {
+		return testEventNotifiers(notifiers)
+	}
+	for _, r := range rules {
+		if !r.matches(e) {
+			continue
+		}
+		var routed []notifications.Notifier
+		for _, n := range notifiers {
+			for _, name := range r.Notifiers {
+				if strings.EqualFold(strings.TrimSpace(name), n.Name()) {
+					routed = append(routed, n)
+					break
+				}
+			}
+		}
+		return routed
+	}
+	return notifiers
+}
+
+// routedDigest is the part of a state digest headed to one set of notifiers.
+type routedDigest struct {
+	notifiers []notifications.Notifier
+	digest    stateDigest
+}
+
+// splitDigestByRoute divides a state digest so that events sharing the same
+// routed notifiers are sent together. Events routed nowhere are dropped.
+func splitDigestByRoute(g stateDigest, notifiers []notifications.Notifier, rules []notifierRoute) []routedDigest {
+	if len(rules) == 0 && !hasTestEvent(g.events) {
+		return []routedDigest{{notifiers: notifiers, digest: g}}
+	}
+	byKey := make(map[string]*routedDigest)
+	var keys []string
+	for _, e := range g.events {
+		routed := routeNotifiers(e, notifiers, rules)
+		if len(routed) == 0 {
+			slog.Warn("notifier routes matched no configured notifier", "stage", "notify", "event_id", e.ID, "state", eventState(e), "event_name", e.Name.Text)
+			continue
+		}
+		names := make([]string, 0, len(routed))
+		for _, n := range routed {
+			names = append(names, n.Name())
+		}
+		key := strings.Join(names, ",")
+		part, ok := byKey[key]
+		if !ok {
+			part = &routedDigest{notifiers: routed, digest: stateDigest{state: g.state}}
+			byKey[key] = part
+			keys = append(keys, key)
+		}
+		part.digest.events = append(part.digest.events, e)
+	}
+	sort.Strings(keys)
+	parts := make([]routedDigest, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, *byKey[k])
+	}
+	return parts
+}
+
+func hasTestEvent(events []event) bool {
+	for _, e := range events {
+		if e.test {
+			return true
+		}
+	}
+	return false
+}
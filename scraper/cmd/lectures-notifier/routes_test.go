@@ -0,0 +1,103 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/notifications"
+)
+
+func notifierNames(ns []notifications.Notifier) []string {
+	names := make([]string, 0, len(ns))
+	for _, n := range ns {
+		names = append(names, n.Name())
+	}
+	return names
+}
+
+func TestRouteNotifiers(t *testing.T) {
+	notifiers := []notifications.Notifier{namedNotifier("ntfy"), namedNotifier("discord"), namedNotifier("webhook")}
+	start := time.Now().Add(48 * time.Hour)
+	inPerson := upcomingEvent("1", "Pints of Science", "Montreal", start)
+	inPerson.Venue.Address.Region = "QC"
+	online := inPerson
+	online.OnlineEvent = true
+	free, paid := true, false
+	freeEvent := inPerson
+	freeEvent.IsFree = &free
+	paidEvent := inPerson
+	paidEvent.IsFree = &paid
+	ontario := upcomingEvent("2", "Brain Night", "Toronto", start)
+	ontario.Venue.Address.Region = "ON"
+
+	tests := []struct {
+		name   string
+		routes string
+		e      event
+		want   []string
+	}{
+		{"no rules", ``, inPerson, []string{"ntfy", "discord", "webhook"}},
+		{"online matches", `[{"online": true, "notifiers": ["discord"]}]`, online, []string{"discord"}},
+		{"online does not match", `[{"online": true, "notifiers": ["discord"]}]`, inPerson, []string{"ntfy", "discord", "webhook"}},
+		{"free matches", `[{"free": true, "notifiers": ["webhook"]}]`, freeEvent, []string{"webhook"}},
+		{"free skips paid", `[{"free": true, "notifiers": ["webhook"]}]`, paidEvent, []string{"ntfy", "discord", "webhook"}},
+		{"free skips unknown price", `[{"free": true, "notifiers": ["webhook"]}]`, inPerson, []string{"ntfy", "discord", "webhook"}},
+		{"state matches case-insensitively", `[{"states": ["qc"], "notifiers": ["ntfy"]}]`, inPerson, []string{"ntfy"}},
+		{"state does not match", `[{"states": ["QC"], "notifiers": ["ntfy"]}]`, ontario, []string{"ntfy", "discord", "webhook"}},
+		{"first match wins", `[{"states": ["QC"], "notifiers": ["discord"]}, {"online": false, "notifiers": ["ntfy"]}]`, inPerson, []string{"discord"}},
+		{"every attribute must match", `[{"online": true, "states": ["QC"], "notifiers": ["discord"]}]`, inPerson, []string{"ntfy", "discord", "webhook"}},
+		{"keeps notifier order", `[{"online": true, "notifiers": ["webhook", "ntfy"]}]`, online, []string{"ntfy", "webhook"}},
+		{"unknown notifier routes nowhere", `[{"online": true, "notifiers": ["slack"]}]`, online, []string{}},
+		{"unknown names are skipped", `[{"online": true, "notifiers": ["slack", "Discord"]}]`, online, []string{"discord"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes, err := parseNotifierRoutes(tt.routes)
+			if err != nil {
+				t.Fatalf("parseNotifierRoutes() error = %v", err)
+			}
+			if got := notifierNames(routeNotifiers(tt.e, notifiers, routes)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("routeNotifiers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNotifierRoutesRejectsInvalid(t *testing.T) {
+	for _, raw := range []string{`{"online": true}`, `[{"online": true}]`, `[{"online": true, "notifiers": []}]`} {
+		if _, err := parseNotifierRoutes(raw); err == nil {
+			t.Errorf("parseNotifierRoutes(%s) = nil error, want a rejection", raw)
+		}
+	}
+}
+
+func TestSplitDigestByRoute(t *testing.T) {
+	notifiers := []notifications.Notifier{namedNotifier("ntfy"), namedNotifier("discord")}
+	start := time.Now().Add(48 * time.Hour)
+	inPerson := upcomingEvent("1", "Pints of Science", "Montreal", start)
+	online := upcomingEvent("2", "Brain Night", "Montreal", start)
+	online.OnlineEvent = true
+	nowhere := upcomingEvent("3", "Quiz", "Montreal", start)
+	free := true
+	nowhere.IsFree = &free
+
+	routes, err := parseNotifierRoutes(`[{"free": true, "notifiers": ["slack"]}, {"online": true, "notifiers": ["discord"]}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := splitDigestByRoute(stateDigest{state: "QC", events: []event{inPerson, online, nowhere}}, notifiers, routes)
+	got := make(map[string][]string)
+	for _, p := range parts {
+		for _, n := range p.notifiers {
+			got[n.Name()] = append(got[n.Name()], eventIDs(p.digest.events)...)
+		}
+	}
+	for _, ids := range got {
+		sort.Strings(ids)
+	}
+	if want := map[string][]string{"ntfy": {"1"}, "discord": {"1", "2"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("splitDigestByRoute() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultRunLockTTL outlives the run's own 3 minute timeout, so a crashed
+// run cannot hold the lock for longer than one schedule slot or so.
+const defaultRunLockTTL = 5 * time.Minute
+
+// runLockKey is held by the run in progress when RUN_LOCK is enabled.
+func runLockKey() string {
+	return redisKeyPrefix + "run_lock"
+}
+
+// releaseRunLockScript deletes the lock only if it still holds our token, so
+// a run that outlived its TTL cannot release a newer run's lock.
+var releaseRunLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// acquireRunLock claims the run lock. It returns a release func and true
+// when this run may proceed, or false when another run holds the lock.
+// Redis errors are logged and the run proceeds unlocked, as dedupe does.
+func acquireRunLock(ctx context.Context, redisClient *redis.Client, ttl time.Duration, m *metrics.Metrics) (func(), bool) {
+	token := fmt.Sprintf("%d-%d", os.Getpid(), time.Now().UnixNano())
+	acquired, err := redisClient.SetNX(ctx, runLockKey(), token, ttl).Result()
+	if err != nil {
+		log.Printf("redis setnx failed for %s: %v (proceeding without run lock)", runLockKey(), err)
+		recordRedisError(err, m)
+		return func() {}, true
+	}
+	if !acquired {
+		holder, _ := redisClient.Get(ctx, runLockKey()).Result()
+		log.Printf("another run holds %s (holder=%s), skipping this run", runLockKey(), holder)
+		return nil, false
+	}
+	log.Printf("acquired run lock %s with TTL %v", runLockKey(), ttl)
+
+	return func() {
+		// The run context may already be done; releasing must still happen.
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := releaseRunLockScript.Run(releaseCtx, redisClient, []string{runLockKey()}, token).Err(); err != nil {
+			log.Printf("failed to release run lock %s: %v", runLockKey(), err)
+			recordRedisError(err, m)
+		}
+	}, true
+}
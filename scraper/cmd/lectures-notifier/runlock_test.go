@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRunNotifierSkipsWhenLockHeld(t *testing.T) {
+	client, fake := newFakeRedis(t)
+	fake.set(runLockKey(), "other-run", time.Minute)
+	fetched := false
+	httpClient := newEventBriteClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched = true
+	}))
+	m := metrics.NewMetrics("", "")
+
+	cfg := appConfig{
+		orgIDs:    []string{"org"},
+		token:     "token",
+		runLock:   runLockConfig{enabled: true, ttl: time.Minute},
+		dedupe:    buildDedupeConfig(),
+		redisDial: func(bool) *redis.Client { return client },
+	}
+	summary, err := runNotifier(context.Background(), httpClient, cfg, true, m)
+	if err != nil {
+		t.Fatalf("runNotifier() error = %v, want a clean exit", err)
+	}
+	if !summary.skippedLocked || fetched {
+		t.Fatalf("skippedLocked = %t, fetched = %t; want the run skipped before fetching", summary.skippedLocked, fetched)
+	}
+	if got := gaugeValue(t, m.LastRunSkippedLocked); got != 1 {
+		t.Fatalf("run_skipped_locked_total = %v, want 1", got)
+	}
+	if v, _ := fake.get(runLockKey()); v != "other-run" {
+		t.Fatalf("%s = %q, want the other run's lock untouched", runLockKey(), v)
+	}
+}
+
+func TestAcquireRunLockReleasesOwnLock(t *testing.T) {
+	client, fake := newFakeRedis(t)
+	ctx := context.Background()
+
+	release, ok := acquireRunLock(ctx, client, time.Minute, nil)
+	if !ok {
+		t.Fatal("acquireRunLock() on a free lock = false")
+	}
+	if _, ok := acquireRunLock(ctx, client, time.Minute, nil); ok {
+		t.Fatal("a second acquireRunLock() succeeded while the lock was held")
+	}
+	if ttl := fake.ttl(runLockKey()); ttl != time.Minute {
+		t.Fatalf("lock TTL = %v, want 1m", ttl)
+	}
+	release()
+	if _, held := fake.get(runLockKey()); held {
+		t.Fatal("release() left the lock in place")
+	}
+}
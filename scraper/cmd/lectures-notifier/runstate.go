@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// lastSuccessKey holds the unix timestamp of the last successful run. It has
+// no TTL so it survives process restarts and long gaps between runs.
+func lastSuccessKey() string {
+	return redisKeyPrefix + "last_success"
+}
+
+// emptyRunsKey counts successful runs in a row that sent no notifications,
+// so a stuck upstream can be told apart from a quiet week.
+func emptyRunsKey() string {
+	return redisKeyPrefix + "consecutive_empty_runs"
+}
+
+// recordTimeSinceLastSuccess exposes how long ago the previous successful run
+// finished. On the first-ever run the key is missing and nothing is recorded.
+func recordTimeSinceLastSuccess(ctx context.Context, redisClient *redis.Client, now time.Time, m *metrics.Metrics) {
+	if redisClient == nil {
+		return
+	}
+	v, err := redisClient.Get(ctx, lastSuccessKey()).Result()
+	if errors.Is(err, redis.Nil) {
+		log.Printf("no previous successful run recorded in redis (%s)", lastSuccessKey())
+		return
+	}
+	if err != nil {
+		log.Printf("redis get failed for %s: %v", lastSuccessKey(), err)
+		recordRedisError(err, m)
+		return
+	}
+	secs, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		log.Printf("ignoring malformed %s value %q: %v", lastSuccessKey(), v, err)
+		return
+	}
+	since := now.Sub(time.Unix(secs, 0))
+	log.Printf("last successful run was %v ago", since.Round(time.Second))
+	m.RecordTimeSinceLastSuccess(since)
+}
+
+func persistLastSuccess(ctx context.Context, redisClient *redis.Client, now time.Time, m *metrics.Metrics) {
+	if redisClient == nil {
+		return
+	}
+	if err := redisClient.Set(ctx, lastSuccessKey(), strconv.FormatInt(now.Unix(), 10), 0).Err(); err != nil {
+		log.Printf("redis set failed for %s: %v", lastSuccessKey(), err)
+		recordRedisError(err, m)
+	}
+}
+
+// updateEmptyRunStreak bumps the empty-run counter when nothing was sent and
+// resets it otherwise, returning the new streak. It returns 0 without Redis.
+func updateEmptyRunStreak(ctx context.Context, redisClient *redis.Client, notified int, m *metrics.Metrics) int64 {
+	if redisClient == nil {
+		return 0
+	}
+	if notified > 0 {
+		if err := redisClient.Set(ctx, emptyRunsKey(), 0, 0).Err(); err != nil {
+			log.Printf("redis set failed for %s: %v", emptyRunsKey(), err)
+			recordRedisError(err, m)
+		}
+		m.RecordConsecutiveEmptyRuns(0)
+		return 0
+	}
+	n, err := redisClient.Incr(ctx, emptyRunsKey()).Result()
+	if err != nil {
+		log.Printf("redis incr failed for %s: %v", emptyRunsKey(), err)
+		recordRedisError(err, m)
+		return 0
+	}
+	log.Printf("no notifications sent for %d consecutive runs", n)
+	m.RecordConsecutiveEmptyRuns(n)
+	return n
+}
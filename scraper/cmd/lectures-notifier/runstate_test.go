@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func gaugeValue(t *testing.T, g prometheus.Gauge) float64 {
+	t.Helper()
+	var out dto.Metric
+	if err := g.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	return out.GetGauge().GetValue()
+}
+
+func TestLastSuccessGauge(t *testing.T) {
+	client, fake := newFakeRedis(t)
+	ctx := context.Background()
+	now := time.Unix(1_800_000_000, 0)
+
+	// The first-ever run has no key and leaves the gauge alone.
+	m := metrics.NewMetrics("", "")
+	m.SecondsSinceLastSuccess.Set(-1)
+	recordTimeSinceLastSuccess(ctx, client, now, m)
+	if got := gaugeValue(t, m.SecondsSinceLastSuccess); got != -1 {
+		t.Fatalf("gauge after first-ever run = %v, want it untouched", got)
+	}
+
+	persistLastSuccess(ctx, client, now, m)
+	if v, _ := fake.get(lastSuccessKey()); v != strconv.FormatInt(now.Unix(), 10) {
+		t.Fatalf("%s = %q, want %d", lastSuccessKey(), v, now.Unix())
+	}
+	if ttl := fake.ttl(lastSuccessKey()); ttl != 0 {
+		t.Fatalf("%s TTL = %v, want none", lastSuccessKey(), ttl)
+	}
+
+	recordTimeSinceLastSuccess(ctx, client, now.Add(90*time.Minute), m)
+	if got := gaugeValue(t, m.SecondsSinceLastSuccess); got != 5400 {
+		t.Fatalf("gauge = %v, want 5400 seconds", got)
+	}
+}
+
+func TestEmptyRunStreak(t *testing.T) {
+	client, fake := newFakeRedis(t)
+	ctx := context.Background()
+	m := metrics.NewMetrics("", "")
+
+	for want := int64(1); want <= 3; want++ {
+		if got := updateEmptyRunStreak(ctx, client, 0, m); got != want {
+			t.Fatalf("updateEmptyRunStreak(empty run %d) = %d", want, got)
+		}
+	}
+	if got := gaugeValue(t, m.ConsecutiveEmptyRuns); got != 3 {
+		t.Fatalf("gauge = %v, want 3", got)
+	}
+	if got := updateEmptyRunStreak(ctx, client, 2, m); got != 0 {
+		t.Fatalf("updateEmptyRunStreak(after a send) = %d, want 0", got)
+	}
+	if v, _ := fake.get(emptyRunsKey()); v != "0" || gaugeValue(t, m.ConsecutiveEmptyRuns) != 0 {
+		t.Fatalf("%s = %q after a send, want reset to 0", emptyRunsKey(), v)
+	}
+	if got := updateEmptyRunStreak(ctx, client, 0, m); got != 1 {
+		t.Fatalf("updateEmptyRunStreak(after reset) = %d, want 1", got)
+	}
+}
+
+func TestPingEmptyRunsAlertsAtThreshold(t *testing.T) {
+	var pings []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pings = append(pings, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name      string
+		separate  bool
+		emptyRuns int64
+		want      []string
+	}{
+		{"below threshold", false, 2, nil},
+		{"at threshold", false, 3, []string{"/main/log"}},
+		{"separate check below threshold", true, 2, []string{"/empty"}},
+		{"separate check at threshold", true, 3, []string{"/empty/fail"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pings = nil
+			cfg := appConfig{healthchecksPingURL: srv.URL + "/main", emptyRunsAlert: 3}
+			if tc.separate {
+				cfg.emptyRunsPingURL = srv.URL + "/empty"
+			}
+			pingEmptyRuns(context.Background(), srv.Client(), cfg, tc.emptyRuns)
+			if !reflect.DeepEqual(pings, tc.want) {
+				t.Fatalf("pings = %v, want %v", pings, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/notifications"
+)
+
+const (
+	testEventName   = "[TEST] Lectures on Tap pipeline check"
+	testEventRegion = "TEST"
+	// testEventNotifier is the only destination with a test channel: ntfy
+	// publishes test notifications to the "<base>-test" topic alone.
+	testEventNotifier = "ntfy"
+)
+
+// syntheticTestEvent builds an available event one day out whose ID is unique
+// per run. It bypasses filters and dedupe and is only routed to the test
+// destination.
+func syntheticTestEvent(now time.Time) event {
+	available := true
+	e := event{
+		ID:                 fmt.Sprintf("test-%d", now.UnixNano()),
+		Venue:              &eventVenue{},
+		TicketAvailability: &ticketAvailability{HasAvailableTickets: &available},
+		test:               true,
+	}
+	e.Name.Text = testEventName
+	e.Start.Local = now.Add(24 * time.Hour).Format("2006-01-02T15:04:05")
+	e.Venue.Address.City = "Test"
+	e.Venue.Address.Region = testEventRegion
+	return e
+}
+
+// testEventNotifiers returns the notifiers that may receive the synthetic
+// test event, so it never reaches a subscriber-facing channel.
+func testEventNotifiers(notifiers []notifications.Notifier) []notifications.Notifier {
+	var routed []notifications.Notifier
+	for _, n := range notifiers {
+		if n.Name() == testEventNotifier {
+			routed = append(routed, n)
+		}
+	}
+	return routed
+}
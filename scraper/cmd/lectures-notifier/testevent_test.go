@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/notifications"
+)
+
+type namedNotifier string
+
+func (n namedNotifier) Name() string { return string(n) }
+
+func (namedNotifier) Notify(context.Context, notifications.Notification) error { return nil }
+
+func TestSyntheticTestEventBypassesFilters(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	e := syntheticTestEvent(now)
+	if e.ID == syntheticTestEvent(now.Add(time.Nanosecond)).ID {
+		t.Fatalf("test event IDs should be unique per run, got %s twice", e.ID)
+	}
+	if !isTicketsAvailable(e) {
+		t.Fatalf("test event should be available")
+	}
+
+	filterCfg := filterConfig{priceFilter: priceFilterPaid, keywords: []string{"philosophy"}, skipSalesEnded: true}
+	got, available, _ := filterEvents(context.Background(), []event{e}, nil, dedupeConfig{byURL: true}, filterCfg, now, nil, nil)
+	if available != 1 || len(got) != 1 || got[0].ID != e.ID {
+		t.Fatalf("filterEvents() = %d events (%d available), want the test event", len(got), available)
+	}
+}
+
+func TestSyntheticTestEventRoutesToTestDestinationOnly(t *testing.T) {
+	e := syntheticTestEvent(time.Now())
+	notifiers := []notifications.Notifier{namedNotifier("ntfy"), namedNotifier("discord"), namedNotifier("webhook")}
+
+	routed := routeNotifiers(e, notifiers, nil)
+	if len(routed) != 1 || routed[0].Name() != "ntfy" {
+		t.Fatalf("routeNotifiers() = %v, want ntfy only", routed)
+	}
+	parts := splitDigestByRoute(stateDigest{state: testEventRegion, events: []event{e}}, notifiers, nil)
+	if len(parts) != 1 || len(parts[0].notifiers) != 1 || parts[0].notifiers[0].Name() != "ntfy" {
+		t.Fatalf("splitDigestByRoute() = %+v, want one ntfy part", parts)
+	}
+	if n := eventNotification(e, messageConfig{}, ""); !n.Test {
+		t.Fatalf("eventNotification() should mark the test event")
+	}
+}
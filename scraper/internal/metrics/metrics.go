@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -15,37 +18,61 @@ import (
 // All execution metrics are recorded at the end of each run to avoid zombie/stale metrics in the Pushgateway.
 type Metrics struct {
 	// Execution and status metrics
-	LastSuccessTimestamp   prometheus.Gauge
-	LastRunSuccess         prometheus.Gauge
+	LastSuccessTimestamp    prometheus.Gauge
+	LastRunSuccess          prometheus.Gauge
 	LastRunDurationSeconds  prometheus.Gauge
 	ExecutionDurationSecs   prometheus.Histogram
+	SecondsSinceLastSuccess prometheus.Gauge
+	ConsecutiveEmptyRuns    prometheus.Gauge
 
 	// Event processing volume metrics for the last run
-	LastRunItemsProcessed        prometheus.Gauge
-	LastRunItemsAvailable        prometheus.Gauge
-	LastRunItemsNotified         prometheus.Gauge
-	LastRunItemsDeduplicated     prometheus.Gauge
-	LastRunItemsSoldOut          prometheus.Gauge
-	LastRunItemsWithoutStartTime prometheus.Gauge
+	LastRunItemsProcessed          prometheus.Gauge
+	LastRunItemsAvailable          prometheus.Gauge
+	LastRunItemsNotified           prometheus.Gauge
+	LastRunItemsWouldNotify        prometheus.Gauge
+	LastRunItemsDeduplicated       prometheus.Gauge
+	LastRunItemsSoldOut            prometheus.Gauge
+	LastRunItemsRestocked          prometheus.Gauge
+	LastRunItemsCanceled           prometheus.Gauge
+	LastRunItemsWithoutStartTime   prometheus.Gauge
+	LastRunItemsPriceFiltered      prometheus.Gauge
+	LastRunItemsKeywordFiltered    prometheus.Gauge
+	LastRunItemsSalesEnded         prometheus.Gauge
+	LastRunItemsRemindersSent      prometheus.Gauge
+	LastRunItemsBudgetSkipped      prometheus.Gauge
+	LastRunSkippedLocked           prometheus.Gauge
+	LastRunItemsRecurringCollapsed prometheus.Gauge
 
 	// Redis metrics for the last run
 	LastRunRedisConnectionErrors  prometheus.Gauge
 	LastRunRedisOperationErrors   prometheus.Gauge
+	LastRunRedisMisconfigErrors   prometheus.Gauge
 	LastRunRedisConnectionRetries prometheus.Histogram
 
 	// API and external service metrics for the last run
 	LastRunEventBriteFetchErrors       prometheus.Gauge
 	LastRunEventBriteFetchDurationSecs prometheus.Histogram
 	LastRunEventBritePagesFetched      prometheus.Gauge
+	LastRunEventBriteAuthErrors        prometheus.Gauge
+	LastRunEventBriteRateLimitWaits    prometheus.Gauge
+	LastRunEventBriteOrganizerErrors   prometheus.Gauge
+	LastRunEventBriteRateLimitWaitSecs prometheus.Gauge
 
 	LastRunNtfyPublishErrors       prometheus.Gauge
 	LastRunNtfyPublishDurationSecs prometheus.Histogram
 	LastRunNtfyPublishes           prometheus.Gauge
 
-	registry *prometheus.Registry
-	pusher   *push.Pusher
+	registry     *prometheus.Registry
+	pusher       *push.Pusher
+	pushTimeout  time.Duration
+	textfilePath string
+	// textfileOpenMetrics writes the textfile in OpenMetrics format instead
+	// of the Prometheus text format.
+	textfileOpenMetrics bool
 }
 
+const defaultPushTimeout = 10 * time.Second
+
 // NewMetrics creates a new Metrics instance configured with the batch job metrics.
 func NewMetrics(pushgatewayURL, jobName string) *Metrics {
 	m := &Metrics{
@@ -66,6 +93,14 @@ func NewMetrics(pushgatewayURL, jobName string) *Metrics {
 			Help:    "Execution duration distribution across runs",
 			Buckets: []float64{0.5, 1, 2, 5, 10, 30, 60, 120, 300},
 		}),
+		SecondsSinceLastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_seconds_since_last_success",
+			Help: "Seconds between the start of this execution and the last successful one, persisted in Redis",
+		}),
+		ConsecutiveEmptyRuns: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_consecutive_empty_runs",
+			Help: "Successful executions in a row that sent no notifications, persisted in Redis",
+		}),
 
 		LastRunItemsProcessed: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "scraper_last_run_items_processed_total",
@@ -79,6 +114,10 @@ func NewMetrics(pushgatewayURL, jobName string) *Metrics {
 			Name: "scraper_last_run_items_notified_total",
 			Help: "Number of events notified in the last execution",
 		}),
+		LastRunItemsWouldNotify: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_last_run_items_would_notify_total",
+			Help: "Number of events that would have notified in the last DEDUP_ANALYZE execution",
+		}),
 		LastRunItemsDeduplicated: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "scraper_last_run_items_deduplicated_total",
 			Help: "Number of events deduplicated in the last execution",
@@ -87,10 +126,46 @@ func NewMetrics(pushgatewayURL, jobName string) *Metrics {
 			Name: "scraper_last_run_items_sold_out_total",
 			Help: "Number of events sold out in the last execution",
 		}),
+		LastRunItemsRestocked: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_last_run_items_restocked_total",
+			Help: "Number of previously sold-out events renotified after a restock in the last execution",
+		}),
+		LastRunItemsCanceled: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_last_run_items_canceled_total",
+			Help: "Number of previously notified events announced as canceled in the last execution",
+		}),
 		LastRunItemsWithoutStartTime: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "scraper_last_run_items_without_start_time_total",
 			Help: "Number of events without start time in the last execution",
 		}),
+		LastRunItemsPriceFiltered: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_last_run_items_price_filtered_total",
+			Help: "Number of events skipped by the price filter in the last execution",
+		}),
+		LastRunItemsKeywordFiltered: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_last_run_items_keyword_filtered_total",
+			Help: "Number of events skipped by the keyword allow/deny lists in the last execution",
+		}),
+		LastRunItemsSalesEnded: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_last_run_items_sales_ended_total",
+			Help: "Number of upcoming events skipped because ticket sales had closed in the last execution",
+		}),
+		LastRunItemsRemindersSent: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_last_run_items_reminders_sent_total",
+			Help: "Number of reminder notifications sent for upcoming events in the last execution",
+		}),
+		LastRunSkippedLocked: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_last_run_skipped_locked_total",
+			Help: "1 when the last execution was skipped because another run held the Redis run lock",
+		}),
+		LastRunItemsBudgetSkipped: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_last_run_items_budget_skipped_total",
+			Help: "Number of events left unnotified because the soft run budget ran out in the last execution",
+		}),
+		LastRunItemsRecurringCollapsed: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_last_run_items_recurring_collapsed_total",
+			Help: "Number of later recurring occurrences collapsed into the soonest one in the last execution",
+		}),
 
 		LastRunRedisConnectionErrors: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "scraper_last_run_redis_connection_errors_total",
@@ -100,6 +175,10 @@ func NewMetrics(pushgatewayURL, jobName string) *Metrics {
 			Name: "scraper_last_run_redis_operation_errors_total",
 			Help: "Number of Redis operation errors in the last execution",
 		}),
+		LastRunRedisMisconfigErrors: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_last_run_redis_misconfig_errors_total",
+			Help: "Number of Redis WRONGTYPE or auth errors in the last execution",
+		}),
 		LastRunRedisConnectionRetries: prometheus.NewHistogram(prometheus.HistogramOpts{
 			Name:    "scraper_last_run_redis_connection_retries",
 			Help:    "Distribution of Redis connection retry counts",
@@ -119,6 +198,22 @@ func NewMetrics(pushgatewayURL, jobName string) *Metrics {
 			Name: "scraper_last_run_eventbrite_pages_fetched_total",
 			Help: "Number of EventBrite API pages fetched in the last execution",
 		}),
+		LastRunEventBriteAuthErrors: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_last_run_eventbrite_auth_errors_total",
+			Help: "Number of EventBrite API requests rejected with 401/403 in the last execution",
+		}),
+		LastRunEventBriteRateLimitWaits: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_last_run_eventbrite_rate_limit_waits_total",
+			Help: "Number of EventBrite 429 responses waited out in the last execution",
+		}),
+		LastRunEventBriteOrganizerErrors: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_last_run_eventbrite_organizer_errors_total",
+			Help: "Number of EventBrite organizers whose events could not be fetched in the last execution",
+		}),
+		LastRunEventBriteRateLimitWaitSecs: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "scraper_last_run_eventbrite_rate_limit_wait_seconds",
+			Help: "Total time spent waiting on EventBrite rate limits in the last execution",
+		}),
 
 		LastRunNtfyPublishErrors: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "scraper_last_run_ntfy_publish_errors_total",
@@ -135,24 +230,42 @@ func NewMetrics(pushgatewayURL, jobName string) *Metrics {
 		}),
 	}
 
+	m.pushTimeout = defaultPushTimeout
 	m.registry = prometheus.NewRegistry()
 	m.registry.MustRegister(
 		m.LastSuccessTimestamp,
 		m.LastRunSuccess,
 		m.LastRunDurationSeconds,
 		m.ExecutionDurationSecs,
+		m.SecondsSinceLastSuccess,
+		m.ConsecutiveEmptyRuns,
 		m.LastRunItemsProcessed,
 		m.LastRunItemsAvailable,
 		m.LastRunItemsNotified,
+		m.LastRunItemsWouldNotify,
 		m.LastRunItemsDeduplicated,
 		m.LastRunItemsSoldOut,
+		m.LastRunItemsRestocked,
+		m.LastRunItemsCanceled,
 		m.LastRunItemsWithoutStartTime,
+		m.LastRunItemsPriceFiltered,
+		m.LastRunItemsKeywordFiltered,
+		m.LastRunItemsSalesEnded,
+		m.LastRunItemsRemindersSent,
+		m.LastRunItemsBudgetSkipped,
+		m.LastRunSkippedLocked,
+		m.LastRunItemsRecurringCollapsed,
 		m.LastRunRedisConnectionErrors,
 		m.LastRunRedisOperationErrors,
+		m.LastRunRedisMisconfigErrors,
 		m.LastRunRedisConnectionRetries,
 		m.LastRunEventBriteFetchErrors,
 		m.LastRunEventBriteFetchDurationSecs,
 		m.LastRunEventBritePagesFetched,
+		m.LastRunEventBriteAuthErrors,
+		m.LastRunEventBriteRateLimitWaits,
+		m.LastRunEventBriteOrganizerErrors,
+		m.LastRunEventBriteRateLimitWaitSecs,
 		m.LastRunNtfyPublishErrors,
 		m.LastRunNtfyPublishDurationSecs,
 		m.LastRunNtfyPublishes,
@@ -199,6 +312,22 @@ func (m *Metrics) RecordExecutionFailure(ctx context.Context, duration time.Dura
 	log.Printf("metrics: execution failed (duration: %v, error: %s)", duration, errorMsg)
 }
 
+// RecordTimeSinceLastSuccess records how long ago the previous successful execution finished.
+func (m *Metrics) RecordTimeSinceLastSuccess(since time.Duration) {
+	if m == nil {
+		return
+	}
+	m.SecondsSinceLastSuccess.Set(since.Seconds())
+}
+
+// RecordConsecutiveEmptyRuns records how many successful executions in a row sent nothing.
+func (m *Metrics) RecordConsecutiveEmptyRuns(n int64) {
+	if m == nil {
+		return
+	}
+	m.ConsecutiveEmptyRuns.Set(float64(n))
+}
+
 // RecordEventsProcessed records the number of events processed.
 func (m *Metrics) RecordEventsProcessed(count int) {
 	if m == nil {
@@ -215,7 +344,7 @@ func (m *Metrics) RecordEventsAvailable(count int) {
 	m.LastRunItemsAvailable.Add(float64(count))
 }
 
-// RecordEventNotified records an event that was notified.
+// RecordEventNotified records an event delivered to at least one destination.
 func (m *Metrics) RecordEventNotified() {
 	if m == nil {
 		return
@@ -223,6 +352,14 @@ func (m *Metrics) RecordEventNotified() {
 	m.LastRunItemsNotified.Inc()
 }
 
+// RecordEventsWouldNotify records how many events a read-only dedupe analysis would notify.
+func (m *Metrics) RecordEventsWouldNotify(count int) {
+	if m == nil {
+		return
+	}
+	m.LastRunItemsWouldNotify.Set(float64(count))
+}
+
 // RecordEventDeduplicated records an event that was deduplicated.
 func (m *Metrics) RecordEventDeduplicated() {
 	if m == nil {
@@ -239,6 +376,22 @@ func (m *Metrics) RecordEventSoldOut() {
 	m.LastRunItemsSoldOut.Inc()
 }
 
+// RecordEventRestocked records a sold-out event that became available again.
+func (m *Metrics) RecordEventRestocked() {
+	if m == nil {
+		return
+	}
+	m.LastRunItemsRestocked.Inc()
+}
+
+// RecordEventCanceled records a cancellation notice sent for a previously notified event.
+func (m *Metrics) RecordEventCanceled() {
+	if m == nil {
+		return
+	}
+	m.LastRunItemsCanceled.Inc()
+}
+
 // RecordEventWithoutStartTime records an event without start time.
 func (m *Metrics) RecordEventWithoutStartTime() {
 	if m == nil {
@@ -247,6 +400,62 @@ func (m *Metrics) RecordEventWithoutStartTime() {
 	m.LastRunItemsWithoutStartTime.Inc()
 }
 
+// RecordEventPriceFiltered records an event skipped by the price filter.
+func (m *Metrics) RecordEventPriceFiltered() {
+	if m == nil {
+		return
+	}
+	m.LastRunItemsPriceFiltered.Inc()
+}
+
+// RecordEventKeywordFiltered records an event skipped by the keyword allow/deny lists.
+func (m *Metrics) RecordEventKeywordFiltered() {
+	if m == nil {
+		return
+	}
+	m.LastRunItemsKeywordFiltered.Inc()
+}
+
+// RecordEventSalesEnded records an event skipped because its ticket sales had closed.
+func (m *Metrics) RecordEventSalesEnded() {
+	if m == nil {
+		return
+	}
+	m.LastRunItemsSalesEnded.Inc()
+}
+
+// RecordReminderSent records a reminder notification delivered for an upcoming event.
+func (m *Metrics) RecordReminderSent() {
+	if m == nil {
+		return
+	}
+	m.LastRunItemsRemindersSent.Inc()
+}
+
+// RecordRunSkippedLocked records a run that exited because another run held the lock.
+func (m *Metrics) RecordRunSkippedLocked() {
+	if m == nil {
+		return
+	}
+	m.LastRunSkippedLocked.Inc()
+}
+
+// RecordEventsBudgetSkipped records events skipped because the soft run budget ran out.
+func (m *Metrics) RecordEventsBudgetSkipped(count int) {
+	if m == nil {
+		return
+	}
+	m.LastRunItemsBudgetSkipped.Add(float64(count))
+}
+
+// RecordEventsRecurringCollapsed records recurring occurrences collapsed into the soonest one.
+func (m *Metrics) RecordEventsRecurringCollapsed(count int) {
+	if m == nil {
+		return
+	}
+	m.LastRunItemsRecurringCollapsed.Add(float64(count))
+}
+
 // RecordEventBriteFetch records an EventBrite fetch operation.
 func (m *Metrics) RecordEventBriteFetch(duration time.Duration, err error) {
 	if m == nil {
@@ -271,6 +480,31 @@ func (m *Metrics) RecordEventBriteFetchPageDuration(duration time.Duration) {
 	m.LastRunEventBritePagesFetched.Inc()
 }
 
+// RecordEventBriteAuthError records an EventBrite request rejected due to the token.
+func (m *Metrics) RecordEventBriteAuthError() {
+	if m == nil {
+		return
+	}
+	m.LastRunEventBriteAuthErrors.Inc()
+}
+
+// RecordEventBriteOrganizerError records an organizer whose events could not be fetched.
+func (m *Metrics) RecordEventBriteOrganizerError() {
+	if m == nil {
+		return
+	}
+	m.LastRunEventBriteOrganizerErrors.Inc()
+}
+
+// RecordEventBriteRateLimitWait records a wait taken after EventBrite returned 429.
+func (m *Metrics) RecordEventBriteRateLimitWait(wait time.Duration) {
+	if m == nil {
+		return
+	}
+	m.LastRunEventBriteRateLimitWaits.Inc()
+	m.LastRunEventBriteRateLimitWaitSecs.Add(wait.Seconds())
+}
+
 // RecordNtfyPublish records an ntfy publish operation.
 func (m *Metrics) RecordNtfyPublish(duration time.Duration, err error) {
 	if m == nil {
@@ -301,6 +535,14 @@ func (m *Metrics) RecordRedisOperationError() {
 	m.LastRunRedisOperationErrors.Inc()
 }
 
+// RecordRedisMisconfigError records a WRONGTYPE or auth error, which retrying won't fix.
+func (m *Metrics) RecordRedisMisconfigError() {
+	if m == nil {
+		return
+	}
+	m.LastRunRedisMisconfigErrors.Inc()
+}
+
 // RecordRedisConnectionRetries records the number of retries for Redis connection.
 func (m *Metrics) RecordRedisConnectionRetries(attempts int) {
 	if m == nil {
@@ -315,7 +557,13 @@ func (m *Metrics) Push(ctx context.Context) error {
 		return nil
 	}
 
-	log.Printf("pushing metrics to Pushgateway")
+	if m.pushTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.pushTimeout)
+		defer cancel()
+	}
+
+	log.Printf("pushing metrics to Pushgateway (timeout: %v)", m.pushTimeout)
 	if err := m.pusher.PushContext(ctx); err != nil {
 		log.Printf("metrics: failed to push to Pushgateway: %v", err)
 		return fmt.Errorf("failed to push metrics to Pushgateway: %w", err)
@@ -324,8 +572,38 @@ func (m *Metrics) Push(ctx context.Context) error {
 	return nil
 }
 
+// WriteTextfile writes all metrics in the node_exporter textfile-collector
+// format, or as OpenMetrics when METRICS_TEXTFILE_FORMAT=openmetrics.
+func (m *Metrics) WriteTextfile() error {
+	if m == nil || m.textfilePath == "" {
+		return nil
+	}
+
+	write := prometheus.WriteToTextfile
+	if m.textfileOpenMetrics {
+		write = writeOpenMetricsTextfile
+	}
+	if err := write(m.textfilePath, m.registry); err != nil {
+		log.Printf("metrics: failed to write textfile %s: %v", m.textfilePath, err)
+		return fmt.Errorf("failed to write metrics textfile: %w", err)
+	}
+	log.Printf("metrics: wrote textfile %s", m.textfilePath)
+	return nil
+}
+
 // InitializeMetricsFromEnv creates and configures metrics from environment variables.
-func InitializeMetricsFromEnv(isLocal bool) *Metrics {
+// client, when non-nil, is used for Pushgateway requests (e.g. to trust a private CA).
+func InitializeMetricsFromEnv(isLocal bool, client *http.Client) *Metrics {
+	m := newMetricsFromEnv(isLocal, client)
+	m.textfilePath = strings.TrimSpace(os.Getenv("METRICS_TEXTFILE_PATH"))
+	m.textfileOpenMetrics = strings.EqualFold(strings.TrimSpace(os.Getenv("METRICS_TEXTFILE_FORMAT")), "openmetrics")
+	if m.textfilePath != "" {
+		log.Printf("metrics: textfile output enabled at %s (openmetrics=%t)", m.textfilePath, m.textfileOpenMetrics)
+	}
+	return m
+}
+
+func newMetricsFromEnv(isLocal bool, client *http.Client) *Metrics {
 	if isLocal {
 		log.Printf("metrics: running in local mode, Pushgateway disabled")
 		return NewMetrics("", "")
@@ -354,6 +632,21 @@ func InitializeMetricsFromEnv(isLocal bool) *Metrics {
 	if groupingKey != "" {
 		m.pusher = m.pusher.Grouping("instance", groupingKey)
 	}
+	if client != nil {
+		m.pusher = m.pusher.Client(client)
+	}
+	if username := strings.TrimSpace(os.Getenv("PUSHGATEWAY_USERNAME")); username != "" {
+		m.pusher = m.pusher.BasicAuth(username, os.Getenv("PUSHGATEWAY_PASSWORD"))
+		log.Printf("metrics: Pushgateway basic auth enabled for user %s", username)
+	}
+
+	if v := strings.TrimSpace(os.Getenv("PUSHGATEWAY_TIMEOUT_SECONDS")); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			m.pushTimeout = time.Duration(secs) * time.Second
+		} else {
+			log.Printf("metrics: invalid PUSHGATEWAY_TIMEOUT_SECONDS %q, using default %v", v, defaultPushTimeout)
+		}
+	}
 
 	return m
 }
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// writeOpenMetricsTextfile is prometheus.WriteToTextfile for the OpenMetrics
+// exposition format: it writes to a temp file next to filename and renames
+// it into place so readers never see a partial file.
+func writeOpenMetricsTextfile(filename string, g prometheus.Gatherer) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	mfs, err := g.Gather()
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	enc := expfmt.NewEncoder(tmp, expfmt.NewFormat(expfmt.TypeOpenMetrics), expfmt.WithUnit())
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if _, err := expfmt.FinalizeOpenMetrics(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestWriteOpenMetricsTextfile(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "scraper_test_events_total", Help: "Test events."})
+	reg.MustRegister(c)
+	c.Add(3)
+	// client_golang has no unit option and prometheus.Gatherers drops the
+	// unit when merging, so the unit-carrying family is appended by hand.
+	name, help, unit, value := "scraper_test_run_duration_seconds", "Test run duration.", "seconds", 1.5
+	gauge := dto.MetricType_GAUGE
+	withUnit := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		mfs, err := reg.Gather()
+		return append(mfs, &dto.MetricFamily{Name: &name, Help: &help, Unit: &unit, Type: &gauge, Metric: []*dto.Metric{{Gauge: &dto.Gauge{Value: &value}}}}), err
+	})
+
+	path := filepath.Join(t.TempDir(), "scraper.prom")
+	if err := writeOpenMetricsTextfile(path, withUnit); err != nil {
+		t.Fatalf("writeOpenMetricsTextfile() error = %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	families := parseOpenMetrics(t, string(raw))
+
+	// OpenMetrics types a counter by its family name and keeps the _total
+	// suffix on the sample only.
+	want := map[string]openMetricsFamily{
+		"scraper_test_events":               {typ: "counter", samples: map[string]string{"scraper_test_events_total": "3.0"}},
+		"scraper_test_run_duration_seconds": {typ: "gauge", unit: "seconds", samples: map[string]string{"scraper_test_run_duration_seconds": "1.5"}},
+	}
+	if !reflect.DeepEqual(families, want) {
+		t.Fatalf("parsed families = %+v, want %+v\n%s", families, want, raw)
+	}
+}
+
+type openMetricsFamily struct {
+	typ     string
+	unit    string
+	samples map[string]string
+}
+
+// parseOpenMetrics checks the exposition's structure while reading it: a
+// single # EOF ends it, every family is typed before its samples, and each
+// sample belongs to the family declared above it.
+func parseOpenMetrics(t *testing.T, text string) map[string]openMetricsFamily {
+	t.Helper()
+	body, ok := strings.CutSuffix(text, "# EOF\n")
+	if !ok {
+		t.Fatalf("exposition does not end with # EOF:\n%s", text)
+	}
+	families := make(map[string]openMetricsFamily)
+	current := ""
+	for _, line := range strings.Split(strings.TrimSuffix(body, "\n"), "\n") {
+		if meta, ok := strings.CutPrefix(line, "# "); ok {
+			fields := strings.SplitN(meta, " ", 3)
+			if len(fields) < 3 {
+				t.Fatalf("malformed metadata line %q", line)
+			}
+			f := families[fields[1]]
+			switch fields[0] {
+			case "HELP":
+			case "TYPE":
+				f.typ = fields[2]
+			case "UNIT":
+				if !strings.HasSuffix(fields[1], "_"+fields[2]) {
+					t.Fatalf("family %s does not end with its unit %q", fields[1], fields[2])
+				}
+				f.unit = fields[2]
+			default:
+				t.Fatalf("unexpected metadata line %q", line)
+			}
+			families[fields[1]] = f
+			current = fields[1]
+			continue
+		}
+		sample, value, ok := strings.Cut(line, " ")
+		if !ok {
+			t.Fatalf("malformed sample line %q", line)
+		}
+		f, declared := families[current]
+		if !declared || f.typ == "" {
+			t.Fatalf("sample %q precedes its # TYPE line", line)
+		}
+		if f.typ == "counter" && sample != current+"_total" {
+			t.Fatalf("counter sample %q, want %s_total", sample, current)
+		}
+		if f.typ != "counter" && sample != current {
+			t.Fatalf("sample %q does not belong to family %s", sample, current)
+		}
+		if f.samples == nil {
+			f.samples = make(map[string]string)
+		}
+		f.samples[sample] = value
+		families[current] = f
+	}
+	return families
+}
+
+func TestWriteTextfileDefaultsToPrometheusFormat(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "scraper_test_events_total", Help: "Test events."})
+	reg.MustRegister(c)
+
+	path := filepath.Join(t.TempDir(), "scraper.prom")
+	m := &Metrics{registry: reg, textfilePath: path}
+	if err := m.WriteTextfile(); err != nil {
+		t.Fatalf("WriteTextfile() error = %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(raw); strings.Contains(got, "# EOF") || !strings.Contains(got, "# TYPE scraper_test_events_total counter") {
+		t.Fatalf("textfile is not in the Prometheus text format:\n%s", got)
+	}
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPushHonoursPushgatewayTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+	t.Setenv("PROMETHEUS_PUSHGATEWAY_URL", srv.URL)
+	t.Setenv("PUSHGATEWAY_TIMEOUT_SECONDS", "1")
+
+	m := newMetricsFromEnv(false, srv.Client())
+	if m.pushTimeout != time.Second {
+		t.Fatalf("pushTimeout = %v, want 1s from PUSHGATEWAY_TIMEOUT_SECONDS", m.pushTimeout)
+	}
+	start := time.Now()
+	if err := m.Push(context.Background()); err == nil {
+		t.Fatal("Push() to a gateway that never answers succeeded")
+	}
+	if elapsed := time.Since(start); elapsed < time.Second || elapsed > 3*time.Second {
+		t.Fatalf("Push() returned after %v, want about the 1s timeout", elapsed)
+	}
+}
+
+func TestPushSendsBasicAuth(t *testing.T) {
+	var user, pass string
+	var ok bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok = r.BasicAuth()
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer srv.Close()
+	t.Setenv("PROMETHEUS_PUSHGATEWAY_URL", srv.URL)
+	t.Setenv("PUSHGATEWAY_USERNAME", " pusher ")
+	t.Setenv("PUSHGATEWAY_PASSWORD", "hunter2")
+
+	if err := newMetricsFromEnv(false, srv.Client()).Push(context.Background()); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if !ok || user != "pusher" || pass != "hunter2" {
+		t.Fatalf("Authorization basic credentials = %q/%q (present=%t), want pusher/hunter2", user, pass, ok)
+	}
+
+	t.Setenv("PUSHGATEWAY_USERNAME", "")
+	if err := newMetricsFromEnv(false, srv.Client()).Push(context.Background()); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if ok {
+		t.Fatal("Push() sent basic auth without PUSHGATEWAY_USERNAME")
+	}
+}
@@ -0,0 +1,23 @@
+package notifications
+
+import (
+	"io"
+	"strings"
+)
+
+// DefaultErrorBodyLimit bounds how much of a destination's error response is
+// read into error messages, so HTML error pages don't flood the logs.
+const DefaultErrorBodyLimit = 2048
+
+// readErrorBody reads at most limit bytes of r (<= 0 uses
+// DefaultErrorBodyLimit), appending an ellipsis when the body was longer.
+func readErrorBody(r io.Reader, limit int) string {
+	if limit <= 0 {
+		limit = DefaultErrorBodyLimit
+	}
+	body, _ := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if len(body) <= limit {
+		return string(body)
+	}
+	return strings.ToValidUTF8(string(body[:limit]), "") + "…"
+}
@@ -0,0 +1,47 @@
+package notifications
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadErrorBody(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		limit int
+		want  string
+	}{
+		{"short", "bad request", 16, "bad request"},
+		{"exact", "0123456789", 10, "0123456789"},
+		{"truncated", "0123456789abc", 10, "0123456789…"},
+		{"default limit", strings.Repeat("x", DefaultErrorBodyLimit+1), 0, strings.Repeat("x", DefaultErrorBodyLimit) + "…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readErrorBody(strings.NewReader(tt.body), tt.limit); got != tt.want {
+				t.Fatalf("readErrorBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildNotifiersAppliesErrorBodyLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(strings.Repeat("e", 100)))
+	}))
+	defer srv.Close()
+
+	ns := BuildNotifiers(srv.Client(), Config{WebhookURL: srv.URL, ErrorBodyLimit: 8}, nil)
+	err := ns[0].Notify(context.Background(), Notification{EventID: "1", Title: "Event"})
+	if err == nil {
+		t.Fatal("Notify() = nil, want a status error")
+	}
+	if want := "webhook status 400: eeeeeeee…"; err.Error() != want {
+		t.Fatalf("Notify() = %q, want %q", err, want)
+	}
+}
@@ -6,46 +6,167 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
+// discordMaxEmbeds is the maximum number of embeds Discord accepts per message.
+const discordMaxEmbeds = 10
+
 type DiscordNotifier struct {
 	client     *http.Client
 	webhookURL string
+	retry      RetryPolicy
+	// errorBodyLimit caps the error response kept in errors (<= 0 uses
+	// DefaultErrorBodyLimit).
+	errorBodyLimit int
 }
 
 type discordPayload struct {
-	Content string `json:"content"`
+	Content string         `json:"content,omitempty"`
+	Embeds  []discordEmbed `json:"embeds,omitempty"`
+}
+
+type discordEmbed struct {
+	Title       string              `json:"title,omitempty"`
+	Description string              `json:"description,omitempty"`
+	URL         string              `json:"url,omitempty"`
+	Fields      []discordEmbedField `json:"fields,omitempty"`
+}
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// discordRateLimit is the body Discord returns with a 429.
+type discordRateLimit struct {
+	RetryAfter float64 `json:"retry_after"`
 }
 
-func NewDiscordNotifier(client *http.Client, webhookURL string) *DiscordNotifier {
-	return &DiscordNotifier{client: client, webhookURL: strings.TrimSpace(webhookURL)}
+func NewDiscordNotifier(client *http.Client, webhookURL string, retry RetryPolicy) *DiscordNotifier {
+	return &DiscordNotifier{client: client, webhookURL: strings.TrimSpace(webhookURL), retry: retry}
 }
 
 func (d *DiscordNotifier) Name() string {
 	return "discord"
 }
 
+// Notify posts n as a single embed when it has a title, and as plain
+// content otherwise.
 func (d *DiscordNotifier) Notify(ctx context.Context, n Notification) error {
-	payload, err := json.Marshal(discordPayload{Content: n.Body})
+	if strings.TrimSpace(n.Title) == "" {
+		return d.post(ctx, discordPayload{Content: PlainText(n)})
+	}
+	return d.post(ctx, discordPayload{Embeds: []discordEmbed{notificationEmbed(n)}})
+}
+
+// notificationEmbed shows the event details as embed fields, leaving the
+// description to a precomposed body or the note.
+func notificationEmbed(n Notification) discordEmbed {
+	embed := discordEmbed{
+		Title:       Heading(n),
+		Description: n.Body,
+		URL:         strings.TrimSpace(n.URL),
+	}
+	if embed.Description == "" {
+		embed.Description = strings.TrimSpace(n.Note)
+	}
+	if loc := location(n); loc != "" {
+		embed.Fields = append(embed.Fields, discordEmbedField{Name: "Location", Value: loc, Inline: true})
+	}
+	if !n.Start.IsZero() {
+		// Discord renders <t:unix:F> in each reader's own timezone.
+		embed.Fields = append(embed.Fields, discordEmbedField{Name: "Starts", Value: fmt.Sprintf("<t:%d:F>", n.Start.Unix()), Inline: true})
+	}
+	return embed
+}
+
+// NotifyBatch posts the notifications as embeds, chunked into as many
+// messages as needed to stay within Discord's per-message embed limit. A
+// failed chunk does not stop the rest; its notifications share its error.
+func (d *DiscordNotifier) NotifyBatch(ctx context.Context, ns []Notification) []error {
+	errs := make([]error, 0, len(ns))
+	for _, chunk := range chunkNotifications(ns, discordMaxEmbeds) {
+		embeds := make([]discordEmbed, 0, len(chunk))
+		for _, n := range chunk {
+			if strings.TrimSpace(n.Title) == "" {
+				embeds = append(embeds, discordEmbed{Description: PlainText(n), URL: strings.TrimSpace(n.URL)})
+				continue
+			}
+			embeds = append(embeds, notificationEmbed(n))
+		}
+		err := d.post(ctx, discordPayload{Embeds: embeds})
+		for range chunk {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func (d *DiscordNotifier) post(ctx context.Context, p discordPayload) error {
+	payload, err := json.Marshal(p)
 	if err != nil {
 		return fmt.Errorf("marshal discord payload: %w", err)
 	}
 
-	req, _ := http.NewRequestWithContext(ctx, "POST", d.webhookURL, bytes.NewBuffer(payload))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := d.client.Do(req)
+	resp, err := doWithRetry(ctx, d.retry, func(ctx context.Context) (*http.Response, error) {
+		req, _ := http.NewRequestWithContext(ctx, "POST", d.webhookURL, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := d.client.Do(req)
+		if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+			withRetryAfterFromBody(resp, d.errorBodyLimit)
+		}
+		return resp, err
+	})
 	if err != nil {
 		return fmt.Errorf("post discord webhook: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("discord rate limited after %d attempts: %s", d.retry.MaxAttempts, readErrorBody(resp.Body, d.errorBodyLimit))
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("discord status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("discord status %d: %s", resp.StatusCode, readErrorBody(resp.Body, d.errorBodyLimit))
 	}
 
 	return nil
 }
+
+// withRetryAfterFromBody copies the retry_after from a Discord 429 body into
+// the Retry-After header when the header is missing, so doWithRetry waits as
+// long as Discord asked. The body, cut to limit bytes, is restored for later
+// reads.
+func withRetryAfterFromBody(resp *http.Response, limit int) {
+	if resp.Header.Get("Retry-After") != "" {
+		return
+	}
+	if limit <= 0 {
+		limit = DefaultErrorBodyLimit
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, int64(limit)))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var rl discordRateLimit
+	if err := json.Unmarshal(body, &rl); err != nil || rl.RetryAfter <= 0 {
+		return
+	}
+	resp.Header.Set("Retry-After", strconv.Itoa(int(math.Ceil(rl.RetryAfter))))
+}
+
+func chunkNotifications(ns []Notification, size int) [][]Notification {
+	var chunks [][]Notification
+	for len(ns) > size {
+		chunks = append(chunks, ns[:size])
+		ns = ns[size:]
+	}
+	if len(ns) > 0 {
+		chunks = append(chunks, ns)
+	}
+	return chunks
+}
@@ -0,0 +1,65 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChunkNotificationsBoundary(t *testing.T) {
+	for _, tt := range []struct{ n, wantChunks, wantLast int }{
+		{0, 0, 0},
+		{1, 1, 1},
+		{discordMaxEmbeds, 1, discordMaxEmbeds},
+		{discordMaxEmbeds + 1, 2, 1},
+		{2 * discordMaxEmbeds, 2, discordMaxEmbeds},
+	} {
+		chunks := chunkNotifications(make([]Notification, tt.n), discordMaxEmbeds)
+		if len(chunks) != tt.wantChunks {
+			t.Fatalf("%d notifications: got %d chunks, want %d", tt.n, len(chunks), tt.wantChunks)
+		}
+		if tt.wantChunks > 0 && len(chunks[len(chunks)-1]) != tt.wantLast {
+			t.Fatalf("%d notifications: last chunk has %d, want %d", tt.n, len(chunks[len(chunks)-1]), tt.wantLast)
+		}
+	}
+}
+
+func TestDiscordNotifyBatchReportsPerNotification(t *testing.T) {
+	posts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		var p discordPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil || len(p.Embeds) > discordMaxEmbeds {
+			t.Errorf("post %d: bad payload (%d embeds): %v", posts, len(p.Embeds), err)
+		}
+		if posts == 2 {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	ns := make([]Notification, 2*discordMaxEmbeds+1)
+	for i := range ns {
+		ns[i] = Notification{EventID: fmt.Sprint(i), Title: fmt.Sprintf("Event %d", i)}
+	}
+	d := NewDiscordNotifier(srv.Client(), srv.URL, RetryPolicy{MaxAttempts: 1})
+	errs := d.NotifyBatch(context.Background(), ns)
+
+	if posts != 3 {
+		t.Fatalf("got %d posts, want 3 (a failed chunk must not stop the rest)", posts)
+	}
+	if len(errs) != len(ns) {
+		t.Fatalf("got %d errors, want one per notification (%d)", len(errs), len(ns))
+	}
+	for i, err := range errs {
+		inFailedChunk := i >= discordMaxEmbeds && i < 2*discordMaxEmbeds
+		if (err != nil) != inFailedChunk {
+			t.Fatalf("notification %d: err = %v, want failure only in the second chunk", i, err)
+		}
+	}
+}
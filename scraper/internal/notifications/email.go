@@ -0,0 +1,179 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailConfig holds the SMTP settings for EmailNotifier.
+type EmailConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// EmailNotifier sends each notification as a multipart email over SMTP,
+// upgrading the connection with STARTTLS before authenticating.
+type EmailNotifier struct {
+	cfg EmailConfig
+}
+
+func NewEmailNotifier(cfg EmailConfig) *EmailNotifier {
+	cfg.Host = strings.TrimSpace(cfg.Host)
+	if cfg.Port <= 0 {
+		cfg.Port = 587
+	}
+	return &EmailNotifier{cfg: cfg}
+}
+
+func (e *EmailNotifier) Name() string {
+	return "email"
+}
+
+func (e *EmailNotifier) Notify(ctx context.Context, n Notification) error {
+	if e.cfg.Host == "" || e.cfg.From == "" || len(e.cfg.To) == 0 {
+		return fmt.Errorf("email notifier missing host, from or to address")
+	}
+	msg, err := e.buildMessage(n)
+	if err != nil {
+		return fmt.Errorf("build email: %w", err)
+	}
+	return e.send(ctx, msg)
+}
+
+// emailSubject names the event and, when known, its city.
+func emailSubject(n Notification) string {
+	name := strings.TrimSpace(n.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(strings.TrimSpace(n.Body), "\n")
+	}
+	if prefix := strings.TrimSpace(n.Prefix); prefix != "" {
+		name = prefix + " " + name
+	}
+	if city := strings.TrimSpace(n.City); city != "" {
+		return fmt.Sprintf("Lectures on Tap: %s (%s)", name, city)
+	}
+	return "Lectures on Tap: " + name
+}
+
+// emailText renders the event one detail per line below the subject. A
+// precomposed Body is used as is.
+func emailText(n Notification) string {
+	if n.Body != "" {
+		return n.Body
+	}
+	var lines []string
+	for _, line := range []string{n.Name, n.When, location(n), n.Note, n.URL} {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *EmailNotifier) buildMessage(n Notification) ([]byte, error) {
+	plain := emailText(n)
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	text, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := text.Write([]byte(plain)); err != nil {
+		return nil, err
+	}
+
+	if url := strings.TrimSpace(n.URL); url != "" {
+		part, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/html; charset=utf-8"}})
+		if err != nil {
+			return nil, err
+		}
+		htmlBody := fmt.Sprintf("<p>%s</p>\n<p><a href=\"%s\">View event</a></p>\n",
+			strings.ReplaceAll(html.EscapeString(plain), "\n", "<br>\n"), html.EscapeString(url))
+		if _, err := part.Write([]byte(htmlBody)); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", emailSubject(n)))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// send delivers msg over a fresh connection. Connection and protocol errors
+// are wrapped so callers see them the same way as HTTP notifier failures.
+func (e *EmailNotifier) send(ctx context.Context, msg []byte) error {
+	addr := net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.cfg.Port))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("connect smtp %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, e.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake with %s: %w", addr, err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); !ok {
+		return fmt.Errorf("smtp server %s does not support STARTTLS", addr)
+	}
+	if err := c.StartTLS(&tls.Config{ServerName: e.cfg.Host, MinVersion: tls.VersionTLS12}); err != nil {
+		return fmt.Errorf("smtp starttls with %s: %w", addr, err)
+	}
+	if e.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)); err != nil {
+			return fmt.Errorf("smtp auth with %s: %w", addr, err)
+		}
+	}
+
+	if err := c.Mail(e.cfg.From); err != nil {
+		return fmt.Errorf("smtp MAIL FROM: %w", err)
+	}
+	for _, to := range e.cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s: %w", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		w.Close()
+		return fmt.Errorf("smtp write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp end message: %w", err)
+	}
+	return c.Quit()
+}
@@ -0,0 +1,163 @@
+package notifications
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gordonpn/lectures-on-tap-scraper/internal/metrics"
+)
+
+// Config selects the destinations BuildNotifiers creates; a destination
+// whose URL (or SMTP host) is empty is left out. The ntfy, Discord and
+// webhook timeouts bound each HTTP attempt, so retries get their own time;
+// the email timeout bounds the whole send. Timeouts <= 0 disable the limit.
+type Config struct {
+	NtfyTopicURLs string
+	NtfyToken     string
+	NtfyMarkdown  bool
+	NtfyMaxTopics int
+	NtfyTimeout   time.Duration
+
+	DiscordWebhookURL string
+	DiscordTimeout    time.Duration
+
+	WebhookURL     string
+	WebhookToken   string
+	WebhookSecret  string
+	WebhookTimeout time.Duration
+
+	Email        EmailConfig
+	EmailTimeout time.Duration
+
+	// ErrorBodyLimit caps how much of an HTTP destination's error response
+	// is kept in errors; <= 0 uses DefaultErrorBodyLimit.
+	ErrorBodyLimit int
+}
+
+// ConfigFromEnv reads the destination settings from the environment:
+//
+//   - NTFY_TOPIC_URL (+ NTFY_TOKEN, NTFY_MARKDOWN, NTFY_MAX_TOPICS_PER_EVENT): ntfy
+//   - DISCORD_WEBHOOK_URL: Discord, unless ENABLE_DISCORD_NOTIFIER=false
+//   - WEBHOOK_URL (+ WEBHOOK_TOKEN, WEBHOOK_SECRET): generic JSON webhook
+//
+// NOTIFY_ERROR_BODY_LIMIT_BYTES caps the error response kept from each
+// HTTP destination.
+//
+// Each HTTP attempt (or email send) is limited by NOTIFY_TIMEOUT_SECONDS
+// (default 15), which NTFY_TIMEOUT_SECONDS, DISCORD_TIMEOUT_SECONDS,
+// WEBHOOK_TIMEOUT_SECONDS and SMTP_TIMEOUT_SECONDS override per destination. Email settings are left to
+// the caller.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		NtfyTopicURLs:     strings.TrimSpace(os.Getenv("NTFY_TOPIC_URL")),
+		NtfyToken:         strings.TrimSpace(os.Getenv("NTFY_TOKEN")),
+		NtfyMarkdown:      envEnabled("NTFY_MARKDOWN"),
+		NtfyMaxTopics:     envPositiveInt("NTFY_MAX_TOPICS_PER_EVENT"),
+		NtfyTimeout:       NotifyTimeoutFromEnv("NTFY_TIMEOUT_SECONDS"),
+		DiscordWebhookURL: strings.TrimSpace(os.Getenv("DISCORD_WEBHOOK_URL")),
+		DiscordTimeout:    NotifyTimeoutFromEnv("DISCORD_TIMEOUT_SECONDS"),
+		WebhookURL:        strings.TrimSpace(os.Getenv("WEBHOOK_URL")),
+		WebhookToken:      os.Getenv("WEBHOOK_TOKEN"),
+		WebhookSecret:     os.Getenv("WEBHOOK_SECRET"),
+		WebhookTimeout:    NotifyTimeoutFromEnv("WEBHOOK_TIMEOUT_SECONDS"),
+		EmailTimeout:      NotifyTimeoutFromEnv("SMTP_TIMEOUT_SECONDS"),
+		ErrorBodyLimit:    envPositiveInt("NOTIFY_ERROR_BODY_LIMIT_BYTES"),
+	}
+	if cfg.DiscordWebhookURL != "" && envDisabled("ENABLE_DISCORD_NOTIFIER") {
+		log.Printf("discord notifier disabled via ENABLE_DISCORD_NOTIFIER")
+		cfg.DiscordWebhookURL = ""
+	}
+	return cfg
+}
+
+// BuildNotifiers returns a notifier for every destination set in cfg, in the
+// order ntfy, Discord, webhook, email. Callers pick destinations by Name(),
+// not position. Every notifier runs under its own timeout: HTTP ones are
+// bounded by their retry policy, so a retry is never cut short, and email by
+// its send timeout.
+func BuildNotifiers(client *http.Client, cfg Config, m *metrics.Metrics) []Notifier {
+	retry := func(timeout time.Duration) RetryPolicy {
+		p := DefaultRetryPolicy()
+		p.AttemptTimeout = timeout
+		return p
+	}
+	var notifiers []Notifier
+
+	if cfg.NtfyTopicURLs != "" {
+		ntfy := NewNtfyNotifier(client, cfg.NtfyTopicURLs, cfg.NtfyToken, m, retry(cfg.NtfyTimeout), cfg.NtfyMarkdown, cfg.NtfyMaxTopics)
+		ntfy.errorBodyLimit = cfg.ErrorBodyLimit
+		notifiers = append(notifiers, WithTimeout(ntfy, ntfy.retry.maxElapsed()))
+	}
+	if cfg.DiscordWebhookURL != "" {
+		discord := NewDiscordNotifier(client, cfg.DiscordWebhookURL, retry(cfg.DiscordTimeout))
+		discord.errorBodyLimit = cfg.ErrorBodyLimit
+		notifiers = append(notifiers, WithTimeout(discord, discord.retry.maxElapsed()))
+	}
+	if cfg.WebhookURL != "" {
+		webhook := NewWebhookNotifier(client, cfg.WebhookURL, cfg.WebhookToken, cfg.WebhookSecret, retry(cfg.WebhookTimeout))
+		webhook.errorBodyLimit = cfg.ErrorBodyLimit
+		notifiers = append(notifiers, WithTimeout(webhook, webhook.retry.maxElapsed()))
+	}
+	if strings.TrimSpace(cfg.Email.Host) != "" {
+		notifiers = append(notifiers, WithTimeout(NewEmailNotifier(cfg.Email), cfg.EmailTimeout))
+	}
+
+	names := make([]string, 0, len(notifiers))
+	for _, n := range notifiers {
+		names = append(names, n.Name())
+	}
+	log.Printf("notifiers configured: %s", strings.Join(names, ","))
+	return notifiers
+}
+
+// envDisabled reports whether key is explicitly set to a false value.
+func envDisabled(key string) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(key))) {
+	case "0", "false", "no", "n", "off":
+		return true
+	}
+	return false
+}
+
+// envEnabled reports whether key is explicitly set to a true value.
+func envEnabled(key string) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(key))) {
+	case "1", "true", "yes", "y", "on":
+		return true
+	}
+	return false
+}
+
+// envPositiveInt returns key as a positive integer, or 0 when unset or invalid.
+func envPositiveInt(key string) int {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		log.Printf("invalid %s %q, ignoring", key, v)
+		return 0
+	}
+	return n
+}
+
+// NotifyTimeoutFromEnv returns the Notify timeout for a destination: key if
+// set, else NOTIFY_TIMEOUT_SECONDS, else DefaultNotifyTimeout.
+func NotifyTimeoutFromEnv(key string) time.Duration {
+	for _, k := range []string{key, "NOTIFY_TIMEOUT_SECONDS"} {
+		v := strings.TrimSpace(os.Getenv(k))
+		if v == "" {
+			continue
+		}
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+		log.Printf("invalid %s %q, ignoring", k, v)
+	}
+	return DefaultNotifyTimeout
+}
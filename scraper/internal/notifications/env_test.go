@@ -0,0 +1,48 @@
+package notifications
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func notifierNames(ns []Notifier) []string {
+	names := make([]string, 0, len(ns))
+	for _, n := range ns {
+		names = append(names, n.Name())
+	}
+	return names
+}
+
+func TestBuildNotifiersFollowsConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want []string
+	}{
+		{"none", Config{}, []string{}},
+		{"discord without ntfy", Config{DiscordWebhookURL: "https://discord.test/api/webhooks/1/x"}, []string{"discord"}},
+		{"all", Config{
+			NtfyTopicURLs:     "https://ntfy.test/lectures",
+			DiscordWebhookURL: "https://discord.test/api/webhooks/1/x",
+			WebhookURL:        "https://hooks.test/lectures",
+			Email:             EmailConfig{Host: "smtp.test", From: "a@test", To: []string{"b@test"}},
+			NtfyTimeout:       time.Second,
+		}, []string{"ntfy", "discord", "webhook", "email"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notifierNames(BuildNotifiers(nil, tt.cfg, nil)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("BuildNotifiers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnvDiscordDisabled(t *testing.T) {
+	t.Setenv("DISCORD_WEBHOOK_URL", "https://discord.test/api/webhooks/1/x")
+	t.Setenv("ENABLE_DISCORD_NOTIFIER", "false")
+	if cfg := ConfigFromEnv(); cfg.DiscordWebhookURL != "" {
+		t.Fatalf("DiscordWebhookURL = %q, want it cleared by ENABLE_DISCORD_NOTIFIER=false", cfg.DiscordWebhookURL)
+	}
+}
@@ -0,0 +1,67 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Result is the outcome of sending a notification to one destination.
+type Result struct {
+	Notifier string
+	Err      error
+}
+
+// MultiNotifier fans a notification out to several destinations at once.
+type MultiNotifier struct {
+	notifiers []Notifier
+}
+
+func NewMultiNotifier(notifiers ...Notifier) *MultiNotifier {
+	return &MultiNotifier{notifiers: notifiers}
+}
+
+func (m *MultiNotifier) Name() string {
+	return "multi"
+}
+
+// Notifiers returns the wrapped destinations.
+func (m *MultiNotifier) Notifiers() []Notifier {
+	return m.notifiers
+}
+
+// Notify sends n to every destination concurrently and returns the joined
+// errors of those that failed; one failure does not stop the others.
+func (m *MultiNotifier) Notify(ctx context.Context, n Notification) error {
+	var errs []error
+	for _, r := range m.NotifyAll(ctx, n) {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Notifier, r.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NotifyAll sends n to every destination concurrently and returns each
+// one's result in the order the notifiers were given.
+func (m *MultiNotifier) NotifyAll(ctx context.Context, n Notification) []Result {
+	results := make([]Result, len(m.notifiers))
+	var wg sync.WaitGroup
+	for i, ntf := range m.notifiers {
+		wg.Add(1)
+		go func(i int, ntf Notifier) {
+			defer wg.Done()
+			err := ntf.Notify(ctx, n)
+			results[i] = Result{Notifier: ntf.Name(), Err: err}
+			if err != nil {
+				log.Printf("notify via %s failed for event %s: %v", ntf.Name(), n.EventID, err)
+				return
+			}
+			log.Printf("notify via %s ok for event %s", ntf.Name(), n.EventID)
+		}(i, ntf)
+	}
+	wg.Wait()
+	return results
+}
@@ -1,13 +1,39 @@
 package notifications
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
-// Notification captures the destination-agnostic message payload.
+// Notification captures the destination-agnostic message payload. Event
+// notifications leave Body empty and carry structured fields that each
+// notifier renders in its own format; a non-empty Body is a precomposed
+// message (such as a digest) that is sent as is.
 type Notification struct {
 	EventID string
 	Body    string
 	State   string
 	URL     string
+	// Tag is an optional series/category label; ntfy publishes it to its own topic.
+	Tag string
+	// Name and City describe the event for destinations with a subject line.
+	Name string
+	City string
+	// Title is an optional heading shown above the body where supported.
+	Title string
+	// Tags are short labels; ntfy shows known emoji names as icons.
+	Tags []string
+	// Start is the event start time, zero when unknown.
+	Start time.Time
+	// When is Start formatted for the reader's locale.
+	When string
+	// Prefix leads the message, such as a reminder or cancellation marker.
+	Prefix string
+	// Note is a short remark about the event, such as remaining capacity.
+	Note string
+	// Test marks a synthetic pipeline check; ntfy publishes it to the
+	// "<base>-test" topic only.
+	Test bool
 }
 
 // Notifier publishes notifications to a single destination.
@@ -15,3 +41,11 @@ type Notifier interface {
 	Name() string
 	Notify(ctx context.Context, n Notification) error
 }
+
+// BatchNotifier is implemented by destinations that can deliver several
+// notifications in as few messages as possible (used by digest mode).
+// NotifyBatch returns one error per notification, nil where it was delivered.
+type BatchNotifier interface {
+	Notifier
+	NotifyBatch(ctx context.Context, ns []Notification) []error
+}
@@ -3,67 +3,186 @@ package notifications
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"math/rand"
+	"mime"
 	"net/http"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gordonpn/lectures-on-tap-scraper/internal/metrics"
 )
 
 type NtfyNotifier struct {
-	client   *http.Client
-	topicURL string
-	token    string
-	metrics  *metrics.Metrics
+	client    *http.Client
+	topicURLs []string
+	token     string
+	metrics   *metrics.Metrics
+	retry     RetryPolicy
+	markdown  bool
+	maxTopics int
+	// errorBodyLimit caps the error response kept in errors (<= 0 uses
+	// DefaultErrorBodyLimit).
+	errorBodyLimit int
 }
 
-func NewNtfyNotifier(client *http.Client, topicURL, token string, m *metrics.Metrics) *NtfyNotifier {
-	return &NtfyNotifier{client: client, topicURL: strings.TrimSpace(topicURL), token: strings.TrimSpace(token), metrics: m}
+// DefaultNtfyMaxTopics covers the base, state and tag topics of one event.
+const DefaultNtfyMaxTopics = 3
+
+// NewNtfyNotifier accepts a comma-separated list of topic URLs for the same
+// logical topic hosted on different servers; each one receives every message.
+// markdown asks ntfy to render message bodies as Markdown. maxTopics caps the
+// distinct topics one event is published to on each server (<= 0 uses
+// DefaultNtfyMaxTopics).
+func NewNtfyNotifier(client *http.Client, topicURLs, token string, m *metrics.Metrics, retry RetryPolicy, markdown bool, maxTopics int) *NtfyNotifier {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, u := range strings.Split(topicURLs, ",") {
+		if u = strings.TrimSpace(u); u == "" {
+			continue
+		}
+		if seen[u] {
+			log.Printf("ignoring duplicate ntfy topic URL %s", u)
+			continue
+		}
+		seen[u] = true
+		urls = append(urls, u)
+	}
+	if maxTopics <= 0 {
+		maxTopics = DefaultNtfyMaxTopics
+	}
+	return &NtfyNotifier{client: client, topicURLs: urls, token: strings.TrimSpace(token), metrics: m, retry: retry, markdown: markdown, maxTopics: maxTopics}
 }
 
 func (n *NtfyNotifier) Name() string {
 	return "ntfy"
 }
 
+// Notify publishes to every configured server and succeeds if at least one
+// server accepted the notification. A topic URL derived on more than one
+// server (say a base topic that equals another's state topic) is published
+// to only once.
 func (n *NtfyNotifier) Notify(ctx context.Context, note Notification) error {
-	if err := n.publish(ctx, n.topicURL, note.Body, note.URL); err != nil {
-		return err
+	if len(n.topicURLs) == 0 {
+		return fmt.Errorf("no ntfy topic URL configured")
+	}
+	var published sync.Map
+	if len(n.topicURLs) == 1 {
+		return n.notifyServer(ctx, n.topicURLs[0], note, &published)
 	}
 
-	stateSlug := stateTopicSlug(note.State)
-	if stateSlug == "" {
-		if strings.TrimSpace(note.State) != "" {
-			log.Printf("skipping state-specific ntfy publish for event %s: derived empty state slug", note.EventID)
+	errs := make([]error, len(n.topicURLs))
+	var wg sync.WaitGroup
+	for i, topicURL := range n.topicURLs {
+		wg.Add(1)
+		go func(i int, topicURL string) {
+			defer wg.Done()
+			if err := n.notifyServer(ctx, topicURL, note, &published); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", topicURL, err)
+			}
+		}(i, topicURL)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+			log.Printf("ntfy server publish failed for event %s: %v", note.EventID, err)
 		}
-		return nil
+	}
+	if failed == len(n.topicURLs) {
+		return fmt.Errorf("all %d ntfy servers failed: %w", failed, errors.Join(errs...))
+	}
+	return nil
+}
+
+// ntfyTopic is one topic an event is published to and what it was derived from.
+type ntfyTopic struct {
+	url  string
+	kind string
+	key  string
+}
+
+// eventTopics lists the distinct topics for note on one server: the base
+// topic, then the state and tag topics, capped at maxTopics. A test
+// notification goes to the "<base>-test" topic alone.
+func (n *NtfyNotifier) eventTopics(topicURL string, note Notification) []ntfyTopic {
+	base := strings.TrimSuffix(topicURL, "-")
+	if note.Test {
+		return []ntfyTopic{{url: base + "-test", kind: "test", key: "test"}}
+	}
+	topics := []ntfyTopic{{url: topicURL}}
+	if stateSlug := TopicSlug(note.State); stateSlug != "" {
+		topics = append(topics, ntfyTopic{url: fmt.Sprintf("%s-%s", base, stateSlug), kind: "state", key: strings.ToLower(strings.TrimSpace(note.State))})
+	} else if strings.TrimSpace(note.State) != "" {
+		log.Printf("skipping state-specific ntfy publish for event %s: derived empty state slug", note.EventID)
+	}
+	if tagSlug := TopicSlug(note.Tag); tagSlug != "" {
+		topics = append(topics, ntfyTopic{url: fmt.Sprintf("%s-%s", base, tagSlug), kind: "tag", key: strings.ToLower(strings.TrimSpace(note.Tag))})
 	}
 
-	base := strings.TrimSuffix(n.topicURL, "-")
-	stateTopicURL := fmt.Sprintf("%s-%s", base, stateSlug)
-	if err := n.publish(ctx, stateTopicURL, note.Body, note.URL); err != nil {
-		return fmt.Errorf("state-specific publish failed for state=%s: %w", strings.ToLower(strings.TrimSpace(note.State)), err)
+	seen := make(map[string]bool, len(topics))
+	distinct := topics[:0]
+	for _, t := range topics {
+		if seen[t.url] {
+			continue
+		}
+		seen[t.url] = true
+		distinct = append(distinct, t)
+	}
+	if len(distinct) > n.maxTopics {
+		for _, t := range distinct[n.maxTopics:] {
+			log.Printf("skipping ntfy topic %s for event %s: over the limit of %d topics per event", t.url, note.EventID, n.maxTopics)
+		}
+		distinct = distinct[:n.maxTopics]
+	}
+	return distinct
+}
+
+func (n *NtfyNotifier) notifyServer(ctx context.Context, topicURL string, note Notification, published *sync.Map) error {
+	for _, t := range n.eventTopics(topicURL, note) {
+		if _, dup := published.LoadOrStore(t.url, true); dup {
+			log.Printf("skipping ntfy topic %s for event %s: already published in this notification", t.url, note.EventID)
+			continue
+		}
+		if err := n.publish(ctx, t.url, note); err != nil {
+			if t.kind == "" {
+				return err
+			}
+			return fmt.Errorf("%s-specific publish failed for %s=%s: %w", t.kind, t.kind, t.key, err)
+		}
 	}
 	return nil
 }
 
-func (n *NtfyNotifier) publish(ctx context.Context, topicURL, msg, clickURL string) error {
+func (n *NtfyNotifier) publish(ctx context.Context, topicURL string, note Notification) error {
+	msg := PlainText(note)
+	if n.markdown {
+		msg = Markdown(note)
+	}
+	clickURL := strings.TrimSpace(note.URL)
 	log.Printf("publishing notification to ntfy topic=%s (message size: %d bytes)", topicURL, len(msg))
 
-	const maxAttempts = 5
-	baseDelay := time.Second
-
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
+	resp, err := doWithRetry(ctx, n.retry, func(ctx context.Context) (*http.Response, error) {
 		req, _ := http.NewRequestWithContext(ctx, "POST", topicURL, bytes.NewBufferString(msg))
 		if n.token != "" {
 			req.Header.Set("Authorization", "Bearer "+n.token)
 		}
 		req.Header.Set("Priority", "max")
-		if strings.TrimSpace(clickURL) != "" {
+		if title := Heading(note); title != "" {
+			// Header values must be ASCII; ntfy decodes RFC 2047 encoded words.
+			req.Header.Set("Title", mime.BEncoding.Encode("utf-8", title))
+		}
+		if len(note.Tags) > 0 {
+			req.Header.Set("Tags", strings.Join(note.Tags, ","))
+		}
+		if n.markdown {
+			req.Header.Set("Markdown", "yes")
+		}
+		if clickURL != "" {
 			req.Header.Set("Click", clickURL)
 			req.Header.Set("Actions", fmt.Sprintf("view, Open Link, %s", clickURL))
 		}
@@ -71,72 +190,49 @@ func (n *NtfyNotifier) publish(ctx context.Context, topicURL, msg, clickURL stri
 		startTime := time.Now()
 		resp, err := n.client.Do(req)
 		elapsed := time.Since(startTime)
-
-		if err != nil {
-			log.Printf("error posting to ntfy (attempt %d/%d): %v", attempt, maxAttempts, err)
+		switch {
+		case err != nil:
+			log.Printf("error posting to ntfy: %v", err)
 			n.metrics.RecordNtfyPublish(elapsed, err)
-			if attempt == maxAttempts {
-				return err
-			}
-			wait := retryAfterDelay("", attempt, baseDelay)
-			time.Sleep(wait)
-			continue
-		}
-
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-
-		if resp.StatusCode == http.StatusTooManyRequests {
-			wait := retryAfterDelay(resp.Header.Get("Retry-After"), attempt, baseDelay)
-			log.Printf("ntfy rate limited (attempt %d/%d), waiting %v before retry: %s", attempt, maxAttempts, wait, string(body))
+		case resp.StatusCode == http.StatusTooManyRequests:
 			n.metrics.RecordNtfyPublish(elapsed, fmt.Errorf("rate limited"))
-			if attempt == maxAttempts {
-				return fmt.Errorf("ntfy rate limited after %d attempts: %s", maxAttempts, string(body))
-			}
-			time.Sleep(wait)
-			continue
+		case resp.StatusCode < 200 || resp.StatusCode >= 300:
+			n.metrics.RecordNtfyPublish(elapsed, fmt.Errorf("ntfy status %d", resp.StatusCode))
+		default:
+			n.metrics.RecordNtfyPublish(elapsed, nil)
 		}
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
 
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			err := fmt.Errorf("ntfy status %d: %s", resp.StatusCode, string(body))
-			log.Printf("error response from ntfy: %v", err)
-			n.metrics.RecordNtfyPublish(elapsed, err)
-			return err
-		}
+	body := readErrorBody(resp.Body, n.errorBodyLimit)
+	resp.Body.Close()
 
-		n.metrics.RecordNtfyPublish(elapsed, nil)
-		log.Printf("ntfy publish ok | topic=%s | bytes=%d | msg=%s", topicURL, len(msg), msg)
-		return nil
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("ntfy rate limited after %d attempts: %s", n.retry.MaxAttempts, body)
 	}
 
-	return fmt.Errorf("ntfy publish failed after %d attempts", maxAttempts)
-}
-
-func retryAfterDelay(header string, attempt int, base time.Duration) time.Duration {
-	if header != "" {
-		if secs, err := strconv.Atoi(header); err == nil && secs >= 0 {
-			return time.Duration(secs) * time.Second
-		}
-		if t, err := http.ParseTime(header); err == nil {
-			d := time.Until(t)
-			if d > 0 {
-				return d
-			}
-		}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err := fmt.Errorf("ntfy status %d: %s", resp.StatusCode, body)
+		log.Printf("error response from ntfy: %v", err)
+		return err
 	}
 
-	backoff := base * time.Duration(1<<uint(attempt-1))
-	jitter := time.Duration(rand.Int63n(int64(base)))
-	return backoff + jitter
+	log.Printf("ntfy publish ok | topic=%s | bytes=%d | msg=%s", topicURL, len(msg), msg)
+	return nil
 }
 
-func stateTopicSlug(state string) string {
-	stateLower := strings.ToLower(strings.TrimSpace(state))
-	if stateLower == "" {
+// TopicSlug reduces a state or tag to the lowercase alphanumerics used as an
+// ntfy topic suffix, which also keeps it safe in the comma-separated Tags header.
+func TopicSlug(value string) string {
+	lower := strings.ToLower(strings.TrimSpace(value))
+	if lower == "" {
 		return ""
 	}
 	var b strings.Builder
-	for _, r := range stateLower {
+	for _, r := range lower {
 		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
 			b.WriteRune(r)
 		}
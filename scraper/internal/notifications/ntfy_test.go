@@ -0,0 +1,188 @@
+package notifications
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestEventTopicsTestNotificationOnly(t *testing.T) {
+	n := NewNtfyNotifier(nil, "https://ntfy.sh/lectures", "", nil, DefaultRetryPolicy(), false, 0)
+	topics := n.eventTopics("https://ntfy.sh/lectures", Notification{EventID: "test-1", State: "TEST", Tag: "science", Test: true})
+	if len(topics) != 1 || topics[0].url != "https://ntfy.sh/lectures-test" {
+		t.Fatalf("eventTopics() = %+v, want only the -test topic", topics)
+	}
+}
+
+func TestEventTopicsTagTopic(t *testing.T) {
+	n := NewNtfyNotifier(nil, "https://ntfy.sh/lectures", "", nil, DefaultRetryPolicy(), false, 0)
+	tests := []struct {
+		name string
+		note Notification
+		want []string
+	}{
+		{"tag and state", Notification{State: "QC", Tag: "Science & Tech"}, []string{"https://ntfy.sh/lectures", "https://ntfy.sh/lectures-qc", "https://ntfy.sh/lectures-sciencetech"}},
+		{"tag only", Notification{Tag: " Talks "}, []string{"https://ntfy.sh/lectures", "https://ntfy.sh/lectures-talks"}},
+		{"no tag", Notification{State: "QC"}, []string{"https://ntfy.sh/lectures", "https://ntfy.sh/lectures-qc"}},
+		{"tag without slug", Notification{Tag: "!!"}, []string{"https://ntfy.sh/lectures"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, topic := range n.eventTopics("https://ntfy.sh/lectures", tc.note) {
+				got = append(got, topic.url)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("eventTopics() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNtfyMarkdownBody(t *testing.T) {
+	var header, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("Markdown")
+		raw, _ := io.ReadAll(r.Body)
+		body = string(raw)
+	}))
+	defer srv.Close()
+
+	note := Notification{EventID: "1", Name: "Pints_of *Science*", City: "Montreal", When: "Thu, Mar 5 at 19:30", Note: "only 5 left!", URL: "https://www.eventbrite.ca/e/1"}
+	n := NewNtfyNotifier(srv.Client(), srv.URL+"/lectures", "", nil, RetryPolicy{MaxAttempts: 1}, true, 1)
+	if err := n.Notify(context.Background(), note); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if header != "yes" {
+		t.Fatalf("Markdown header = %q, want yes", header)
+	}
+	want := "**Pints\\_of \\*Science\\***\nMontreal · Thu, Mar 5 at 19:30\nonly 5 left!\n[Open event](https://www.eventbrite.ca/e/1)"
+	if body != want {
+		t.Fatalf("body = %q, want %q", body, want)
+	}
+
+	n = NewNtfyNotifier(srv.Client(), srv.URL+"/lectures", "", nil, RetryPolicy{MaxAttempts: 1}, false, 1)
+	if err := n.Notify(context.Background(), note); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if header != "" || body != PlainText(note) {
+		t.Fatalf("without markdown got header %q body %q, want plain text", header, body)
+	}
+}
+
+func TestNtfyMultiServerOneDown(t *testing.T) {
+	var paths []string
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	down.Close()
+
+	note := Notification{EventID: "1", Name: "Pints of Science"}
+	n := NewNtfyNotifier(up.Client(), down.URL+"/lectures,"+up.URL+"/lectures", "", nil, RetryPolicy{MaxAttempts: 1}, false, 0)
+	if err := n.Notify(context.Background(), note); err != nil {
+		t.Fatalf("Notify() with one server up error = %v", err)
+	}
+	if len(paths) != 1 || paths[0] != "/lectures" {
+		t.Fatalf("up server got %v, want one publish to /lectures", paths)
+	}
+
+	n = NewNtfyNotifier(up.Client(), down.URL+"/lectures,"+down.URL+"/other", "", nil, RetryPolicy{MaxAttempts: 1}, false, 0)
+	if err := n.Notify(context.Background(), note); err == nil {
+		t.Fatal("Notify() with every server down succeeded")
+	}
+}
+
+func TestNtfyMaxTopicsCapsPublishes(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	// The base, state and tag topics would make three publishes.
+	note := Notification{EventID: "1", Name: "Pints of Science", State: "QC", Tag: "Science"}
+	for _, tc := range []struct {
+		maxTopics int
+		want      []string
+	}{
+		{1, []string{"/lectures"}},
+		{2, []string{"/lectures", "/lectures-qc"}},
+		{0, []string{"/lectures", "/lectures-qc", "/lectures-science"}},
+	} {
+		paths = nil
+		n := NewNtfyNotifier(srv.Client(), srv.URL+"/lectures", "", nil, RetryPolicy{MaxAttempts: 1}, false, tc.maxTopics)
+		if err := n.Notify(context.Background(), note); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+		if !reflect.DeepEqual(paths, tc.want) {
+			t.Fatalf("maxTopics %d published to %v, want %v", tc.maxTopics, paths, tc.want)
+		}
+	}
+}
+
+func TestNtfyPublishesEachTopicOnce(t *testing.T) {
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		counts[r.URL.Path]++
+	}))
+	defer srv.Close()
+
+	note := Notification{EventID: "1", Name: "Pints of Science", State: "QC"}
+	for _, tc := range []struct {
+		name   string
+		topics string
+		want   map[string]int
+	}{
+		{"identical URLs", srv.URL + "/lectures, " + srv.URL + "/lectures", map[string]int{"/lectures": 1, "/lectures-qc": 1}},
+		// The first server's state topic is the second server's base topic.
+		{"overlapping URLs", srv.URL + "/lectures," + srv.URL + "/lectures-qc", map[string]int{"/lectures": 1, "/lectures-qc": 1, "/lectures-qc-qc": 1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clear(counts)
+			n := NewNtfyNotifier(srv.Client(), tc.topics, "", nil, RetryPolicy{MaxAttempts: 1}, false, 0)
+			if err := n.Notify(context.Background(), note); err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+			if !reflect.DeepEqual(counts, tc.want) {
+				t.Fatalf("publishes = %v, want %v", counts, tc.want)
+			}
+		})
+	}
+}
+
+func TestNtfyEmptyURLOmitsClickHeaders(t *testing.T) {
+	var header http.Header
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		raw, _ := io.ReadAll(r.Body)
+		body = string(raw)
+	}))
+	defer srv.Close()
+
+	n := NewNtfyNotifier(srv.Client(), srv.URL+"/lectures", "", nil, RetryPolicy{MaxAttempts: 1}, false, 1)
+	for _, url := range []string{"", "   "} {
+		header, body = nil, ""
+		note := Notification{EventID: "1", Name: "Pints of Science", URL: url}
+		if err := n.Notify(context.Background(), note); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+		if header == nil || body != PlainText(note) {
+			t.Fatalf("message with URL %q not sent: body %q", url, body)
+		}
+		for _, h := range []string{"Click", "Actions"} {
+			if v, ok := header[h]; ok {
+				t.Fatalf("%s header = %q for URL %q, want none", h, v, url)
+			}
+		}
+	}
+}
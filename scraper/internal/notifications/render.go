@@ -0,0 +1,84 @@
+package notifications
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlainText renders n as a single line, "prefix city name (when) note url",
+// for destinations that show short text. A non-empty Body is returned as is.
+func PlainText(n Notification) string {
+	if n.Body != "" {
+		return n.Body
+	}
+	msg := fmt.Sprintf("%s %s (%s)", n.City, n.Name, n.When)
+	if note := strings.TrimSpace(n.Note); note != "" {
+		msg += " " + note
+	}
+	// An event without a URL is still worth announcing, just without a link.
+	if url := strings.TrimSpace(n.URL); url != "" {
+		msg += " " + url
+	}
+	if prefix := strings.TrimSpace(n.Prefix); prefix != "" {
+		msg = prefix + " " + msg
+	}
+	return msg
+}
+
+// Markdown renders n for destinations that format Markdown: the event name
+// in bold, then where and when, the note and a link. A non-empty Body is
+// returned as is.
+func Markdown(n Notification) string {
+	if n.Body != "" {
+		return n.Body
+	}
+	var b strings.Builder
+	if prefix := strings.TrimSpace(n.Prefix); prefix != "" {
+		b.WriteString(escapeMarkdown(prefix) + " ")
+	}
+	fmt.Fprintf(&b, "**%s**", escapeMarkdown(n.Name))
+	var details []string
+	for _, d := range []string{n.City, n.When} {
+		if d = strings.TrimSpace(d); d != "" {
+			details = append(details, d)
+		}
+	}
+	if len(details) > 0 {
+		b.WriteString("\n" + escapeMarkdown(strings.Join(details, " · ")))
+	}
+	if note := strings.TrimSpace(n.Note); note != "" {
+		b.WriteString("\n" + escapeMarkdown(note))
+	}
+	if url := strings.TrimSpace(n.URL); url != "" {
+		fmt.Fprintf(&b, "\n[Open event](%s)", url)
+	}
+	return b.String()
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, "`", "\\`")
+
+// escapeMarkdown keeps event text from being read as Markdown syntax.
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// Heading returns the title shown above a message, led by the prefix.
+func Heading(n Notification) string {
+	title := strings.TrimSpace(n.Title)
+	if prefix := strings.TrimSpace(n.Prefix); prefix != "" && title != "" {
+		return prefix + " " + title
+	}
+	return title
+}
+
+// location joins the city and state, either of which may be empty.
+func location(n Notification) string {
+	loc := strings.TrimSpace(n.City)
+	if state := strings.TrimSpace(n.State); state != "" {
+		if loc != "" {
+			loc += ", "
+		}
+		loc += state
+	}
+	return loc
+}
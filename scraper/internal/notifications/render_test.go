@@ -0,0 +1,173 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// renderNote has every field a notifier may render.
+var renderNote = Notification{
+	EventID: "1",
+	Prefix:  "❌ Canceled:",
+	Title:   "Pints of Science",
+	Name:    "Pints of Science",
+	City:    "Montreal",
+	State:   "QC",
+	When:    "Thu, Mar 5 at 19:30",
+	Start:   time.Date(2026, 3, 5, 19, 30, 0, 0, time.UTC),
+	Note:    "only 5 left!",
+	URL:     "https://www.eventbrite.ca/e/1",
+}
+
+const renderPlainText = "❌ Canceled: Montreal Pints of Science (Thu, Mar 5 at 19:30) only 5 left! https://www.eventbrite.ca/e/1"
+
+// captureServer records the last request's headers and body.
+func captureServer(t *testing.T) (*httptest.Server, *http.Header, *[]byte) {
+	t.Helper()
+	var header http.Header
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &header, &body
+}
+
+func TestNtfyRendering(t *testing.T) {
+	srv, header, body := captureServer(t)
+	n := NewNtfyNotifier(srv.Client(), srv.URL+"/lectures", "", nil, RetryPolicy{MaxAttempts: 1}, false, 1)
+	if err := n.Notify(context.Background(), renderNote); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	title, err := new(mime.WordDecoder).DecodeHeader(header.Get("Title"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "❌ Canceled: Pints of Science" {
+		t.Fatalf("Title = %q", title)
+	}
+	if got := string(*body); got != renderPlainText {
+		t.Fatalf("body = %q, want %q", got, renderPlainText)
+	}
+}
+
+func TestDiscordRendering(t *testing.T) {
+	srv, _, body := captureServer(t)
+	d := NewDiscordNotifier(srv.Client(), srv.URL, RetryPolicy{MaxAttempts: 1})
+	if err := d.Notify(context.Background(), renderNote); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	var got discordPayload
+	if err := json.Unmarshal(*body, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := discordPayload{Embeds: []discordEmbed{{
+		Title:       "❌ Canceled: Pints of Science",
+		Description: "only 5 left!",
+		URL:         "https://www.eventbrite.ca/e/1",
+		Fields: []discordEmbedField{
+			{Name: "Location", Value: "Montreal, QC", Inline: true},
+			{Name: "Starts", Value: "<t:1772739000:F>", Inline: true},
+		},
+	}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("payload = %+v, want %+v", got, want)
+	}
+
+	// Without a title the plain text goes in the message content.
+	untitled := renderNote
+	untitled.Title = ""
+	if err := d.Notify(context.Background(), untitled); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	got = discordPayload{}
+	if err := json.Unmarshal(*body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Content != renderPlainText || got.Embeds != nil {
+		t.Fatalf("untitled payload = %+v, want content %q", got, renderPlainText)
+	}
+}
+
+func TestWebhookRendering(t *testing.T) {
+	srv, _, body := captureServer(t)
+	wh := NewWebhookNotifier(srv.Client(), srv.URL, "", "", RetryPolicy{MaxAttempts: 1})
+	if err := wh.Notify(context.Background(), renderNote); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	var got webhookPayload
+	if err := json.Unmarshal(*body, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := webhookPayload{
+		EventID: "1",
+		Body:    renderPlainText,
+		State:   "QC",
+		URL:     "https://www.eventbrite.ca/e/1",
+		Name:    "Pints of Science",
+		City:    "Montreal",
+		Start:   "2026-03-05T19:30:00Z",
+		When:    "Thu, Mar 5 at 19:30",
+		Prefix:  "❌ Canceled:",
+		Note:    "only 5 left!",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("payload = %+v, want %+v", got, want)
+	}
+}
+
+func TestEmailRendering(t *testing.T) {
+	e := NewEmailNotifier(EmailConfig{Host: "smtp.example.com", From: "bot@example.com", To: []string{"me@example.com"}})
+	raw, err := e.buildMessage(renderNote)
+	if err != nil {
+		t.Fatalf("buildMessage() error = %v", err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Lectures on Tap: ❌ Canceled: Pints of Science (Montreal)"; subject != want {
+		t.Fatalf("Subject = %q, want %q", subject, want)
+	}
+
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make(map[string]string)
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(p)
+		parts[p.Header.Get("Content-Type")] = string(b)
+	}
+	wantText := "Pints of Science\nThu, Mar 5 at 19:30\nMontreal, QC\nonly 5 left!\nhttps://www.eventbrite.ca/e/1"
+	if got := parts["text/plain; charset=utf-8"]; got != wantText {
+		t.Fatalf("text part = %q, want %q", got, wantText)
+	}
+	if got := parts["text/html; charset=utf-8"]; !bytes.Contains([]byte(got), []byte(`<a href="https://www.eventbrite.ca/e/1">View event</a>`)) {
+		t.Fatalf("html part = %q, want a link to the event", got)
+	}
+}
@@ -0,0 +1,137 @@
+package notifications
+
+import (
+	"context"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how HTTP-based notifiers retry failed requests.
+// AttemptTimeout bounds each attempt on its own, so a slow first request
+// still leaves the retries their full time; <= 0 leaves attempts unbounded.
+type RetryPolicy struct {
+	MaxAttempts    int
+	BaseDelay      time.Duration
+	MaxDelay       time.Duration
+	AttemptTimeout time.Duration
+}
+
+// DefaultRetryPolicy returns the policy used by notifiers unless overridden.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 30 * time.Second}
+}
+
+func (p RetryPolicy) delay(retryAfter string, attempt int) time.Duration {
+	wait := retryAfterDelay(retryAfter, attempt, p.BaseDelay)
+	if p.MaxDelay > 0 && wait > p.MaxDelay {
+		wait = p.MaxDelay
+	}
+	return wait
+}
+
+// maxElapsed bounds one request with every retry: each attempt's timeout
+// plus the longest wait between attempts. It is 0 (unbounded) when attempts
+// or waits have no limit.
+func (p RetryPolicy) maxElapsed() time.Duration {
+	if p.AttemptTimeout <= 0 || p.MaxDelay <= 0 {
+		return 0
+	}
+	attempts := max(p.MaxAttempts, 1)
+	return time.Duration(attempts)*p.AttemptTimeout + time.Duration(attempts-1)*p.MaxDelay
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// doWithRetry calls do until it yields a response that should not be retried,
+// retrying transport errors, 429 and 5xx with backoff. Each call gets its own
+// context limited to policy.AttemptTimeout. The final response is returned
+// with its body unread, so callers handle status codes as before; an error is
+// only returned when no response was obtained.
+func doWithRetry(ctx context.Context, policy RetryPolicy, do func(ctx context.Context) (*http.Response, error)) (*http.Response, error) {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = time.Second
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := doAttempt(ctx, policy.AttemptTimeout, do)
+		retryAfter := ""
+		if err == nil {
+			if !isRetryableStatus(resp.StatusCode) || attempt == maxAttempts {
+				return resp, nil
+			}
+			retryAfter = resp.Header.Get("Retry-After")
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, DefaultErrorBodyLimit))
+			resp.Body.Close()
+		} else if attempt == maxAttempts {
+			return nil, err
+		}
+
+		wait := policy.delay(retryAfter, attempt)
+		if err != nil {
+			log.Printf("request failed (attempt %d/%d), retrying in %v: %v", attempt, maxAttempts, wait, err)
+		} else {
+			log.Printf("request returned status %d (attempt %d/%d), retrying in %v", resp.StatusCode, attempt, maxAttempts, wait)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// doAttempt runs one call of do under timeout. The attempt's context lives
+// until the response body is closed, so callers can still read it.
+func doAttempt(ctx context.Context, timeout time.Duration, do func(ctx context.Context) (*http.Response, error)) (*http.Response, error) {
+	if timeout <= 0 {
+		return do(ctx)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	resp, err := do(attemptCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases an attempt's context once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func retryAfterDelay(header string, attempt int, base time.Duration) time.Duration {
+	if header != "" {
+		if secs, err := strconv.Atoi(header); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := http.ParseTime(header); err == nil {
+			d := time.Until(t)
+			if d > 0 {
+				return d
+			}
+		}
+	}
+
+	backoff := base * time.Duration(1<<uint(attempt-1))
+	jitter := time.Duration(rand.Int63n(int64(base)))
+	return backoff + jitter
+}
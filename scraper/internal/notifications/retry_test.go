@@ -0,0 +1,97 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDoWithRetryTimesOutEachAttempt(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, AttemptTimeout: 50 * time.Millisecond}
+	resp, err := doWithRetry(context.Background(), policy, func(ctx context.Context) (*http.Response, error) {
+		req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+		return srv.Client().Do(req)
+	})
+	if err != nil {
+		t.Fatalf("doWithRetry() error = %v, want the second attempt to succeed", err)
+	}
+	defer resp.Body.Close()
+	if body := readErrorBody(resp.Body, 0); resp.StatusCode != http.StatusOK || body != "ok" {
+		t.Fatalf("got status %d body %q, want 200 \"ok\"", resp.StatusCode, body)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("got %d attempts, want 2", got)
+	}
+}
+
+func TestDoWithRetry(t *testing.T) {
+	errTransport := errors.New("connection refused")
+	tests := []struct {
+		name       string
+		replies    []int // status per attempt; 0 fails the attempt in transport
+		wantStatus int
+		wantErr    bool
+		wantCalls  int
+	}{
+		{"success", []int{http.StatusOK}, http.StatusOK, false, 1},
+		{"transport error then success", []int{0, http.StatusOK}, http.StatusOK, false, 2},
+		{"transport errors exhausted", []int{0, 0, 0}, 0, true, 3},
+		{"429 then success", []int{http.StatusTooManyRequests, http.StatusOK}, http.StatusOK, false, 2},
+		{"5xx then success", []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK}, http.StatusOK, false, 3},
+		{"5xx exhausted", []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError}, http.StatusInternalServerError, false, 3},
+		{"4xx not retried", []int{http.StatusBadRequest, http.StatusOK}, http.StatusBadRequest, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(tt.replies[calls-1])
+			}))
+			defer srv.Close()
+
+			policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+			resp, err := doWithRetry(context.Background(), policy, func(ctx context.Context) (*http.Response, error) {
+				calls++
+				if tt.replies[calls-1] == 0 {
+					return nil, errTransport
+				}
+				req, _ := http.NewRequestWithContext(ctx, "POST", srv.URL, nil)
+				return srv.Client().Do(req)
+			})
+			if calls != tt.wantCalls {
+				t.Fatalf("got %d attempts, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, errTransport) {
+					t.Fatalf("doWithRetry() error = %v, want %v", err, errTransport)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("doWithRetry() error = %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package notifications
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultNotifyTimeout bounds one HTTP attempt of a notifier, or a whole
+// email send.
+const DefaultNotifyTimeout = 15 * time.Second
+
+type timeoutNotifier struct {
+	Notifier
+	timeout time.Duration
+}
+
+func (t *timeoutNotifier) Notify(ctx context.Context, n Notification) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.Notifier.Notify(ctx, n)
+}
+
+type timeoutBatchNotifier struct {
+	timeoutNotifier
+	batch BatchNotifier
+}
+
+func (t *timeoutBatchNotifier) NotifyBatch(ctx context.Context, ns []Notification) []error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.batch.NotifyBatch(ctx, ns)
+}
+
+// WithTimeout limits every Notify (and NotifyBatch) on n to d, so one slow
+// destination gives up on its own schedule instead of holding the run. The
+// result still implements BatchNotifier when n does. d <= 0 returns n as is.
+func WithTimeout(n Notifier, d time.Duration) Notifier {
+	if d <= 0 {
+		return n
+	}
+	tn := timeoutNotifier{Notifier: n, timeout: d}
+	if bn, ok := n.(BatchNotifier); ok {
+		return &timeoutBatchNotifier{timeoutNotifier: tn, batch: bn}
+	}
+	return &tn
+}
@@ -0,0 +1,79 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBuildNotifiersWrapsEveryNotifierInTimeout(t *testing.T) {
+	cfg := Config{
+		NtfyTopicURLs:     "https://ntfy.test/lectures",
+		NtfyTimeout:       time.Second,
+		DiscordWebhookURL: "https://discord.test/api/webhooks/1/x",
+		DiscordTimeout:    time.Second,
+		WebhookURL:        "https://hooks.test/lectures",
+		WebhookTimeout:    time.Second,
+		Email:             EmailConfig{Host: "smtp.test", From: "a@test", To: []string{"b@test"}},
+		EmailTimeout:      time.Second,
+	}
+	for _, n := range BuildNotifiers(nil, cfg, nil) {
+		switch n.(type) {
+		case *timeoutNotifier, *timeoutBatchNotifier:
+		default:
+			t.Errorf("%s notifier is %T, want it wrapped in a timeout", n.Name(), n)
+		}
+		if _, ok := n.(*timeoutBatchNotifier); ok != (n.Name() == "discord") {
+			t.Errorf("%s: BatchNotifier support = %v after wrapping", n.Name(), ok)
+		}
+	}
+}
+
+func TestRetryPolicyMaxElapsed(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, MaxDelay: 2 * time.Second, AttemptTimeout: 5 * time.Second}
+	if got, want := p.maxElapsed(), 19*time.Second; got != want {
+		t.Fatalf("maxElapsed() = %v, want %v", got, want)
+	}
+	p.AttemptTimeout = 0
+	if got := p.maxElapsed(); got != 0 {
+		t.Fatalf("maxElapsed() without an attempt timeout = %v, want 0", got)
+	}
+}
+
+func TestSlowNotifierTimesOutWithoutDelayingOthers(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Reading the body lets the server notice the client hanging up.
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer fast.Close()
+
+	once := RetryPolicy{MaxAttempts: 1}
+	multi := NewMultiNotifier(
+		WithTimeout(NewWebhookNotifier(slow.Client(), slow.URL, "", "", once), 100*time.Millisecond),
+		WithTimeout(NewDiscordNotifier(fast.Client(), fast.URL, once), 100*time.Millisecond),
+	)
+
+	start := time.Now()
+	results := multi.NotifyAll(context.Background(), Notification{EventID: "1", Title: "Event"})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("NotifyAll took %v, want it bounded by the slow notifier's timeout", elapsed)
+	}
+	if !errors.Is(results[0].Err, context.DeadlineExceeded) {
+		t.Fatalf("slow notifier error = %v, want a deadline exceeded", results[0].Err)
+	}
+	if results[1].Err != nil {
+		t.Fatalf("fast notifier error = %v, want success", results[1].Err)
+	}
+}
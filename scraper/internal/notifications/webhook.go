@@ -0,0 +1,122 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookNotifier posts each notification as JSON to a generic HTTP endpoint.
+//
+// When a signing secret is set, every request carries:
+//
+//	X-Timestamp:      unix seconds at send time
+//	X-Signature-256:  sha256=<hex HMAC-SHA256(secret, "<X-Timestamp>.<raw body>")>
+//
+// Consumers should recompute the HMAC over the timestamp, a literal ".", and
+// the exact request body bytes, compare in constant time, and reject stale
+// timestamps to prevent replays.
+type WebhookNotifier struct {
+	client *http.Client
+	url    string
+	token  string
+	secret string
+	retry  RetryPolicy
+	// errorBodyLimit caps the error response kept in errors (<= 0 uses
+	// DefaultErrorBodyLimit).
+	errorBodyLimit int
+}
+
+// webhookPayload carries the plain-text body alongside the structured fields
+// it was rendered from, so consumers can build their own message.
+type webhookPayload struct {
+	EventID string   `json:"event_id"`
+	Body    string   `json:"body"`
+	State   string   `json:"state,omitempty"`
+	URL     string   `json:"url,omitempty"`
+	Tag     string   `json:"tag,omitempty"`
+	Name    string   `json:"name,omitempty"`
+	City    string   `json:"city,omitempty"`
+	Start   string   `json:"start,omitempty"`
+	When    string   `json:"when,omitempty"`
+	Prefix  string   `json:"prefix,omitempty"`
+	Note    string   `json:"note,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// NewWebhookNotifier retries connection errors, 429 and 5xx according to
+// retry; other 4xx responses fail immediately.
+func NewWebhookNotifier(client *http.Client, url, token, secret string, retry RetryPolicy) *WebhookNotifier {
+	return &WebhookNotifier{client: client, url: strings.TrimSpace(url), token: strings.TrimSpace(token), secret: secret, retry: retry}
+}
+
+// webhookSignature returns the X-Signature-256 value for body sent at timestamp.
+func webhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (w *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	p := webhookPayload{
+		EventID: n.EventID,
+		Body:    PlainText(n),
+		State:   n.State,
+		URL:     strings.TrimSpace(n.URL),
+		Tag:     n.Tag,
+		Name:    n.Name,
+		City:    n.City,
+		When:    n.When,
+		Prefix:  n.Prefix,
+		Note:    n.Note,
+		Tags:    n.Tags,
+	}
+	if !n.Start.IsZero() {
+		p.Start = n.Start.Format(time.RFC3339)
+	}
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("marshal webhook payload: %w", err)
+	}
+
+	resp, err := doWithRetry(ctx, w.retry, func(ctx context.Context) (*http.Response, error) {
+		// A fresh reader per attempt, since the previous one was consumed.
+		req, err := http.NewRequestWithContext(ctx, "POST", w.url, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if w.token != "" {
+			req.Header.Set("Authorization", "Bearer "+w.token)
+		}
+		if w.secret != "" {
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			req.Header.Set("X-Timestamp", timestamp)
+			req.Header.Set("X-Signature-256", webhookSignature(w.secret, timestamp, payload))
+		}
+		return w.client.Do(req)
+	})
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook status %d: %s", resp.StatusCode, readErrorBody(resp.Body, w.errorBodyLimit))
+	}
+	return nil
+}
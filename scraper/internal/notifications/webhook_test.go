@@ -0,0 +1,51 @@
+package notifications
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(`1700000000.{"event_id":"1"}`))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := webhookSignature("s3cret", "1700000000", []byte(`{"event_id":"1"}`)); got != want {
+		t.Fatalf("webhookSignature() = %q, want %q", got, want)
+	}
+}
+
+func TestWebhookNotifierSignsBody(t *testing.T) {
+	const secret = "s3cret"
+	var timestamp, signature string
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timestamp = r.Header.Get("X-Timestamp")
+		signature = r.Header.Get("X-Signature-256")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	wh := NewWebhookNotifier(srv.Client(), srv.URL, "", secret, RetryPolicy{MaxAttempts: 1})
+	if err := wh.Notify(context.Background(), Notification{EventID: "1", Title: "Event"}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if timestamp == "" {
+		t.Fatal("X-Timestamp header missing")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(signature), []byte(want)) {
+		t.Fatalf("X-Signature-256 = %q, want %q", signature, want)
+	}
+}